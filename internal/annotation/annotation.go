@@ -0,0 +1,235 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package annotation parses network service annotations for cmd-admission-webhook-k8s
+package annotation
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+const (
+	defaultMechanism = "kernel"
+	// maxInterfaceNameLength is IFNAMSIZ of Linux without the terminating null byte.
+	maxInterfaceNameLength = 15
+)
+
+var (
+	mechanismRegexp      = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*$`)
+	networkServiceRegexp = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9._-]*[a-zA-Z0-9])?(@[a-zA-Z0-9]([a-zA-Z0-9.-]*[a-zA-Z0-9])?)?$`)
+	interfaceNameRegexp  = regexp.MustCompile(`^[^/\s:]+$`)
+)
+
+// NetworkService is a network service request of the structured annotation format.
+type NetworkService struct {
+	// NetworkService is the name of the requested network service, optionally with the domain: name@domain.
+	NetworkService string `json:"networkService"`
+	// InterfaceName is the name of the interface in the pod.
+	InterfaceName string `json:"interfaceName,omitempty"`
+	// Mechanism is the mechanism of the interface, "kernel" by default.
+	Mechanism string `json:"mechanism,omitempty"`
+	// Labels are the source labels of the request, values may contain commas.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Resources are additionally requested by the NSM containers for this network service.
+	Resources corev1.ResourceList `json:"resources,omitempty"`
+}
+
+// Request is a network service request of the NS annotation.
+type Request struct {
+	// URL is the NS URL of the request.
+	URL *url.URL
+	// Resources are additionally requested by the NSM containers for this request.
+	Resources corev1.ResourceList
+}
+
+// InterfaceName returns the name of the interface requested by the NS URL, empty if not specified.
+func (r *Request) InterfaceName() string {
+	return strings.Trim(r.URL.Path, "/")
+}
+
+// IsStructured checks whether the annotation value uses the structured format: a JSON or YAML list of objects.
+func IsStructured(v string) bool {
+	v = strings.TrimSpace(v)
+	return strings.HasPrefix(v, "[") || strings.HasPrefix(v, "-")
+}
+
+// Parse parses the annotation value in the URL list or the structured format and expands the placeholders.
+func Parse(v string, p *Placeholders) ([]*Request, error) {
+	if !IsStructured(v) {
+		return parseURLs(v, p)
+	}
+	var services []*NetworkService
+	if err := yaml.NewYAMLOrJSONDecoder(strings.NewReader(v), len(v)+1).Decode(&services); err != nil {
+		return nil, errors.Wrap(err, "malformed structured NS annotation")
+	}
+	var requests []*Request
+	for i, service := range services {
+		if err := service.expand(p, p.Index+i); err != nil {
+			return nil, errors.Wrapf(err, "invalid network service %d of structured NS annotation", i)
+		}
+		u, err := service.URL()
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid network service %d of structured NS annotation", i)
+		}
+		requests = append(requests, &Request{URL: u, Resources: service.Resources})
+	}
+	return requests, nil
+}
+
+func parseURLs(v string, p *Placeholders) ([]*Request, error) {
+	var requests []*Request
+	for _, rawURL := range strings.Split(v, ",") {
+		rawURL = strings.TrimSpace(rawURL)
+		if rawURL == "" {
+			continue
+		}
+		expanded, err := p.expand(rawURL, p.Index+len(requests))
+		if err != nil {
+			return nil, errors.Wrapf(err, "malformed NS annotation: %s", rawURL)
+		}
+		u, err := url.Parse(expanded)
+		if err != nil {
+			return nil, errors.Wrapf(err, "malformed NS annotation: %s", rawURL)
+		}
+		requests = append(requests, &Request{URL: u})
+	}
+	return requests, nil
+}
+
+// Render returns the canonical comma-separated NS URL list and the sum of resources of the requests.
+func Render(requests []*Request) (nsURLs string, resources corev1.ResourceList) {
+	urls := make([]string, 0, len(requests))
+	resources = make(corev1.ResourceList)
+	for _, r := range requests {
+		urls = append(urls, r.URL.String())
+		for name, quantity := range r.Resources {
+			q := resources[name]
+			q.Add(quantity)
+			resources[name] = q
+		}
+	}
+	return strings.Join(urls, ","), resources
+}
+
+// Union returns the requests of base followed by the requests of overlay. A request of base is replaced by
+// the request of overlay with the same interface name, equal NS URLs are requested once.
+func Union(base, overlay []*Request) []*Request {
+	var result []*Request
+	seen := make(map[string]int)
+	add := func(r *Request) {
+		key := r.URL.String()
+		if name := r.InterfaceName(); name != "" {
+			key = "/" + name
+		}
+		if i, ok := seen[key]; ok {
+			result[i] = r
+			return
+		}
+		seen[key] = len(result)
+		result = append(result, r)
+	}
+	for _, r := range base {
+		add(r)
+	}
+	for _, r := range overlay {
+		add(r)
+	}
+	return result
+}
+
+func (n *NetworkService) expand(p *Placeholders, index int) error {
+	var err error
+	if n.NetworkService, err = p.expand(n.NetworkService, index); err != nil {
+		return err
+	}
+	if n.InterfaceName, err = p.expand(n.InterfaceName, index); err != nil {
+		return err
+	}
+	labels := make(map[string]string, len(n.Labels))
+	for key, value := range n.Labels {
+		if labels[key], err = p.expand(value, index); err != nil {
+			return err
+		}
+	}
+	n.Labels = labels
+	return nil
+}
+
+// URL validates the network service and renders it as NS URL: ${mechanism}://${network service}[/${interface name}][?${labels}].
+// Commas in labels are escaped, so the URL can be safely joined into the comma-separated list.
+func (n *NetworkService) URL() (*url.URL, error) {
+	mechanism := n.Mechanism
+	if mechanism == "" {
+		mechanism = defaultMechanism
+	}
+	if !mechanismRegexp.MatchString(mechanism) {
+		return nil, errors.Errorf("not a valid mechanism: %q", mechanism)
+	}
+	if !networkServiceRegexp.MatchString(envRefRegexp.ReplaceAllString(n.NetworkService, "x")) {
+		return nil, errors.Errorf("not a valid network service: %q", n.NetworkService)
+	}
+	u := &url.URL{
+		Scheme: strings.ToLower(mechanism),
+		Host:   n.NetworkService,
+	}
+	if name, domain, ok := strings.Cut(n.NetworkService, "@"); ok {
+		u.User = url.User(name)
+		u.Host = domain
+	}
+	if n.InterfaceName != "" {
+		if !interfaceNameRegexp.MatchString(n.InterfaceName) || !envRefRegexp.MatchString(n.InterfaceName) && len(n.InterfaceName) > maxInterfaceNameLength {
+			return nil, errors.Errorf("not a valid interface name: %q", n.InterfaceName)
+		}
+		u.Path = "/" + n.InterfaceName
+		// keeps env references unescaped, it's ignored if the interface name has to be escaped
+		u.RawPath = u.Path
+	}
+	u.RawQuery = encodeLabels(n.Labels)
+	return u, nil
+}
+
+func encodeLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, key := range keys {
+		parts = append(parts, url.QueryEscape(key)+"="+queryEscape(labels[key]))
+	}
+	return strings.Join(parts, "&")
+}
+
+// queryEscape escapes the label value keeping env references, so they are expanded by kubelet.
+func queryEscape(v string) string {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range envRefRegexp.FindAllStringIndex(v, -1) {
+		sb.WriteString(url.QueryEscape(v[last:loc[0]]))
+		sb.WriteString(v[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	sb.WriteString(url.QueryEscape(v[last:]))
+	return sb.String()
+}
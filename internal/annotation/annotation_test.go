@@ -0,0 +1,149 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package annotation_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/annotation"
+)
+
+func TestIsStructured(t *testing.T) {
+	require.True(t, annotation.IsStructured(`[{"networkService":"ns"}]`))
+	require.True(t, annotation.IsStructured("\n- networkService: ns"))
+	require.False(t, annotation.IsStructured("kernel://ns/nsm-1"))
+	require.False(t, annotation.IsStructured(""))
+}
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		value     string
+		urls      string
+		resources map[string]int64
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:  "URL list",
+			value: " kernel://ns-1/nsm-1?a=b, ,vfio://ns-2@domain ",
+			urls:  "kernel://ns-1/nsm-1?a=b,vfio://ns-2@domain",
+		},
+		{
+			name:  "structured YAML",
+			value: "- networkService: ns-1\n  interfaceName: nsm-1\n  labels: {b: c, a: 'x,y'}\n  resources: {intel/10G: 2}\n- networkService: ns@dom\n  mechanism: VFIO",
+			urls:  "kernel://ns-1/nsm-1?a=x%2Cy&b=c,vfio://ns@dom",
+			resources: map[string]int64{
+				"intel/10G": 2,
+			},
+		},
+		{
+			name:  "structured JSON summing resources",
+			value: `[{"networkService":"ns-1","resources":{"intel/10G":1}},{"networkService":"ns-2","resources":{"intel/10G":2}}]`,
+			urls:  "kernel://ns-1,kernel://ns-2",
+			resources: map[string]int64{
+				"intel/10G": 3,
+			},
+		},
+		{
+			name:  "structured with env reference in long interface name",
+			value: `[{"networkService":"ns","interfaceName":"nsm-$(POD_NAME)","labels":{"pod":"$(POD_NAME)"}}]`,
+			urls:  "kernel://ns/nsm-$(POD_NAME)?pod=$(POD_NAME)",
+		},
+		{
+			name:  "structured with escaped interface name",
+			value: `[{"networkService":"ns","interfaceName":"a?b"}]`,
+			urls:  "kernel://ns/a%3Fb",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			requests, err := annotation.Parse(tc.value, &annotation.Placeholders{})
+			require.NoError(t, err)
+			urls, resources := annotation.Render(requests)
+			require.Equal(t, tc.urls, urls)
+			require.Len(t, resources, len(tc.resources))
+			for name, value := range tc.resources {
+				q := resources[corev1.ResourceName(name)]
+				require.Equal(t, value, q.Value(), name)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for name, value := range map[string]string{
+		"malformed URL":           "kernel://ns/nsm-1,%zz",
+		"malformed structured":    "- networkService: [",
+		"missing network service": `[{"interfaceName":"nsm-1"}]`,
+		"invalid network service": `[{"networkService":"ns/1"}]`,
+		"invalid mechanism":       `[{"networkService":"ns","mechanism":"1kernel"}]`,
+		"long interface name":     `[{"networkService":"ns","interfaceName":"nsm-0123456789ab"}]`,
+		"interface name with /":   `[{"networkService":"ns","interfaceName":"a/b"}]`,
+		"invalid quantity":        `[{"networkService":"ns","resources":{"intel/10G":"x"}}]`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := annotation.Parse(value, &annotation.Placeholders{})
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestUnion(t *testing.T) {
+	parse := func(v string) []*annotation.Request {
+		requests, err := annotation.Parse(v, &annotation.Placeholders{})
+		require.NoError(t, err)
+		return requests
+	}
+	for _, tc := range []struct {
+		name          string
+		base, overlay string
+		urls          string
+	}{
+		{
+			name:    "disjoint",
+			base:    "kernel://ns-1/nsm-1",
+			overlay: "kernel://ns-2/nsm-2",
+			urls:    "kernel://ns-1/nsm-1,kernel://ns-2/nsm-2",
+		},
+		{
+			name:    "overlay replaces same interface name in place",
+			base:    "kernel://ns-1/nsm-1,kernel://ns-2/nsm-2",
+			overlay: "vfio://ns-3/nsm-1",
+			urls:    "vfio://ns-3/nsm-1,kernel://ns-2/nsm-2",
+		},
+		{
+			name:    "equal URLs without interface name are requested once",
+			base:    "kernel://ns-1",
+			overlay: "kernel://ns-1,kernel://ns-2",
+			urls:    "kernel://ns-1,kernel://ns-2",
+		},
+		{
+			name:    "empty base",
+			overlay: "kernel://ns-1",
+			urls:    "kernel://ns-1",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			urls, _ := annotation.Render(annotation.Union(parse(tc.base), parse(tc.overlay)))
+			require.Equal(t, tc.urls, urls)
+		})
+	}
+}
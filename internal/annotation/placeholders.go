@@ -0,0 +1,97 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package annotation
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// These are the env references the runtime placeholders are converted to, the envs are expanded by kubelet.
+const (
+	// PodNameEnvRef references the env with the name of the pod.
+	PodNameEnvRef = "$(POD_NAME)"
+	// NodeNameEnvRef references the env with the name of the node.
+	NodeNameEnvRef = "$(NODE_NAME)"
+)
+
+const labelPlaceholderPrefix = "label:"
+
+var (
+	placeholderRegexp = regexp.MustCompile(`\{\{\s*([^{}]*?)\s*\}\}`)
+	envRefRegexp      = regexp.MustCompile(`\$\([A-Z_]+\)`)
+)
+
+// Placeholders are the values of placeholders expanded in the NS annotation:
+//
+//	{{namespace}}     - namespace of the resource
+//	{{workload}}      - name of the resource
+//	{{label:<key>}}   - value of the label of the pod
+//	{{index}}         - position of the network service in the annotation
+//	{{pod}}, {{node}} - name of the pod and the node, converted to PodNameEnvRef and NodeNameEnvRef
+type Placeholders struct {
+	Namespace string
+	Workload  string
+	Labels    map[string]string
+	// Index is the value of {{index}} for the first network service of the annotation, the next ones are counted from it.
+	Index int
+}
+
+// expand replaces all placeholders of the value. Unknown and malformed placeholders are reported by the error.
+func (p *Placeholders) expand(v string, index int) (string, error) {
+	var err error
+	result := placeholderRegexp.ReplaceAllStringFunc(v, func(match string) string {
+		value, e := p.valueOf(placeholderRegexp.FindStringSubmatch(match)[1], index)
+		if e != nil && err == nil {
+			err = e
+		}
+		return value
+	})
+	if err != nil {
+		return "", err
+	}
+	if strings.Contains(result, "{{") || strings.Contains(result, "}}") {
+		return "", errors.Errorf("malformed placeholder in %q", v)
+	}
+	return result, nil
+}
+
+func (p *Placeholders) valueOf(name string, index int) (string, error) {
+	switch name {
+	case "namespace":
+		return p.Namespace, nil
+	case "workload":
+		return p.Workload, nil
+	case "index":
+		return strconv.Itoa(index), nil
+	case "pod":
+		return PodNameEnvRef, nil
+	case "node":
+		return NodeNameEnvRef, nil
+	}
+	if key, ok := strings.CutPrefix(name, labelPlaceholderPrefix); ok {
+		value, ok := p.Labels[key]
+		if !ok {
+			return "", errors.Errorf("placeholder {{%s}}: the pod has no label %s", name, key)
+		}
+		return value, nil
+	}
+	return "", errors.Errorf("unknown placeholder {{%s}}", name)
+}
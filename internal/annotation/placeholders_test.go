@@ -0,0 +1,83 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package annotation_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/annotation"
+)
+
+func TestParse_Placeholders(t *testing.T) {
+	placeholders := &annotation.Placeholders{
+		Namespace: "tenant",
+		Workload:  "web",
+		Labels:    map[string]string{"app": "shop"},
+		Index:     3,
+	}
+	for _, tc := range []struct {
+		name  string
+		value string
+		urls  string
+	}{
+		{
+			name:  "all placeholders",
+			value: "kernel://{{namespace}}-{{ workload }}/nsm-{{index}}?app={{label:app}}&pod={{pod}}&node={{node}}",
+			urls:  "kernel://tenant-web/nsm-3?app=shop&pod=$(POD_NAME)&node=$(NODE_NAME)",
+		},
+		{
+			name:  "index counted per URL",
+			value: "kernel://ns/nsm-{{index}},kernel://ns/nsm-{{index}}",
+			urls:  "kernel://ns/nsm-3,kernel://ns/nsm-4",
+		},
+		{
+			name:  "index counted per structured item",
+			value: `[{"networkService":"ns","interfaceName":"nsm-{{index}}"},{"networkService":"ns","interfaceName":"nsm-{{index}}","labels":{"pod":"{{pod}}"}}]`,
+			urls:  "kernel://ns/nsm-3,kernel://ns/nsm-4?pod=$(POD_NAME)",
+		},
+		{
+			name:  "pod in structured interface name",
+			value: `[{"networkService":"{{namespace}}","interfaceName":"{{pod}}"}]`,
+			urls:  "kernel://tenant/$(POD_NAME)",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			requests, err := annotation.Parse(tc.value, placeholders)
+			require.NoError(t, err)
+			urls, _ := annotation.Render(requests)
+			require.Equal(t, tc.urls, urls)
+		})
+	}
+}
+
+func TestParse_InvalidPlaceholders(t *testing.T) {
+	placeholders := &annotation.Placeholders{Labels: map[string]string{"app": "shop"}}
+	for name, value := range map[string]string{
+		"unknown":               "kernel://{{unknown}}",
+		"missing label":         "kernel://ns?app={{label:tier}}",
+		"unclosed":              "kernel://{{namespace",
+		"unopened":              "kernel://namespace}}",
+		"unknown in structured": `[{"networkService":"{{unknown}}"}]`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := annotation.Parse(value, placeholders)
+			require.Error(t, err)
+		})
+	}
+}
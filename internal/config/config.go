@@ -2,6 +2,8 @@
 //
 // Copyright (c) 2023-2024 Cisco and/or its affiliates.
 //
+// Copyright (c) 2026 Nordix Foundation.
+//
 // SPDX-License-Identifier: Apache-2.0
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
@@ -29,6 +31,7 @@ import (
 	"fmt"
 	"math/big"
 	"os"
+	"path"
 	"strings"
 	"sync"
 	"time"
@@ -39,33 +42,73 @@ import (
 
 // Config represents env configuration for cmd-admission-webhook-k8s
 type Config struct {
-	Name                  string            `default:"admission-webhook-k8s" desc:"Name of current admission webhook instance" split_words:"true"`
-	ServiceName           string            `default:"default" desc:"Name of service that related to this admission webhook instance" split_words:"true"`
-	Namespace             string            `default:"default" desc:"Namespace where admission webhook is deployed" split_words:"true"`
-	Annotation            string            `default:"networkservicemesh.io" desc:"Name of annotation that means that the resource can be handled by admission-webhook" split_words:"true"`
-	Labels                map[string]string `default:"" desc:"Map of labels and their values that should be appended for each deployment that has Config.Annotation" split_words:"true"`
-	NSURLEnvName          string            `default:"NSM_NETWORK_SERVICES" desc:"Name of env that contains NSURL in initContainers/Containers" split_words:"true"`
-	InitContainerImages   []string          `desc:"List of init containers that should be appended for each deployment that has Config.Annotation" split_words:"true"`
-	ContainerImages       []string          `desc:"List of containers that should be appended for each deployment that has Config.Annotation" split_words:"true"`
-	Envs                  []string          `desc:"Additional Envs that should be appended for each Config.ContainerImages and Config.InitContainerImages" split_words:"true"`
-	WebhookMode           Mode              `default:"spire" desc:"Default 'spire' mode uses spire certificates and external webhook configuration. Set to 'selfregister' to use the automatically generated webhook configuration" split_words:"true"`
-	CertFilePath          string            `desc:"Path to certificate. Preferred use if specified" split_words:"true"`
-	KeyFilePath           string            `desc:"Path to RSA/Ed25519 related to Config.CertFilePath. Preferred use if specified" split_words:"true"`
-	CABundleFilePath      string            `desc:"Path to cabundle file related to Config.CertFilePath. Preferred use if specified" split_words:"true"`
-	OpenTelemetryEndpoint string            `default:"otel-collector.observability.svc.cluster.local:4317" desc:"OpenTelemetry Collector Endpoint" split_words:"true"`
-	MetricsExportInterval time.Duration     `default:"10s" desc:"interval between mertics exports" split_words:"true"`
-	SidecarLimitsMemory   string            `default:"80Mi" desc:"Lower bound of the NSM sidecar memory limit (in k8s resource management units)" split_words:"true"`
-	SidecarLimitsCPU      string            `default:"200m" desc:"Lower bound of the NSM sidecar CPU limit (in k8s resource management units)" split_words:"true"`
-	SidecarRequestsMemory string            `default:"40Mi" desc:"Lower bound of the NSM sidecar requests memory limits (in k8s resource management units)" split_words:"true"`
-	SidecarRequestsCPU    string            `default:"100m" desc:"Lower bound of the NSM sidecar requests CPU limits (in k8s resource management units)" split_words:"true"`
-	PprofEnabled          bool              `default:"false" desc:"is pprof enabled" split_words:"true"`
-	PprofListenOn         string            `default:"localhost:6060" desc:"pprof URL to ListenAndServe" split_words:"true"`
+	Name                               string            `default:"admission-webhook-k8s" desc:"Name of current admission webhook instance" split_words:"true"`
+	ServiceName                        string            `default:"default" desc:"Name of service that related to this admission webhook instance" split_words:"true"`
+	Namespace                          string            `default:"default" desc:"Namespace where admission webhook is deployed" split_words:"true"`
+	Annotation                         string            `default:"networkservicemesh.io" desc:"Name of annotation that means that the resource can be handled by admission-webhook" split_words:"true"`
+	AnnotationMergeStrategy            MergeStrategy     `default:"override" desc:"How the NS annotation of the namespace is combined with the NS annotation of the resource: 'override', 'union' or 'namespace-only'" split_words:"true"`
+	Labels                             map[string]string `default:"" desc:"Map of labels and their values that should be appended for each deployment that has Config.Annotation" split_words:"true"`
+	NSURLEnvName                       string            `default:"NSM_NETWORK_SERVICES" desc:"Name of env that contains NSURL in initContainers/Containers" split_words:"true"`
+	InitContainerImages                []string          `desc:"List of init containers that should be appended for each deployment that has Config.Annotation" split_words:"true"`
+	ContainerImages                    []string          `desc:"List of containers that should be appended for each deployment that has Config.Annotation" split_words:"true"`
+	Envs                               []string          `desc:"Additional Envs that should be appended for each Config.ContainerImages and Config.InitContainerImages: NAME=value or NAME@source=reference for configMapKeyRef, secretKeyRef, fieldRef and resourceFieldRef" split_words:"true"`
+	NameStrategy                       NameStrategy      `default:"hash" desc:"Strategy of NSM_NAME env of NSM containers for pods without generateName: 'hash' of the resource kind, namespace, name and UID, 'random', 'pod-name' or 'template'" split_words:"true"`
+	NameTemplate                       string            `desc:"Go template of NSM_NAME env for the 'template' name strategy, e.g. '$(POD_NAME)-{{ .Namespace }}'" split_words:"true"`
+	EnvsFilePath                       string            `desc:"Path to YAML/JSON file with a list of corev1.EnvVar appended after Config.Envs" split_words:"true"`
+	InitContainerTemplatesFilePath     string            `desc:"Path to YAML/JSON file with a list of init container templates that should be appended for each deployment that has Config.Annotation" split_words:"true"`
+	ContainerTemplatesFilePath         string            `desc:"Path to YAML/JSON file with a list of container templates that should be appended for each deployment that has Config.Annotation" split_words:"true"`
+	ProfilesFilePath                   string            `desc:"Path to YAML/JSON file with a list of named injection profiles" split_words:"true"`
+	ResourceRulesFilePath              string            `desc:"Path to YAML/JSON file with a list of rules mapping NS URL labels and network services to resources of NSM containers (default: sriovToken label values are device plugin resources)" split_words:"true"`
+	ResourceClaimTemplates             map[string]string `default:"" desc:"Map of resource names computed by the resource rules to ResourceClaimTemplates, such resources are requested by pod resource claims (DRA) instead of extended resources" split_words:"true"`
+	ProfileAnnotation                  string            `default:"networkservicemesh.io/profile" desc:"Name of annotation that selects the injection profile for the resource or the default profile for the namespace" split_words:"true"`
+	StatusAnnotation                   string            `default:"networkservicemesh.io/injection-status" desc:"Name of annotation that reports the injection status of the resource: 'injected' or 'skipped:<reason>'" split_words:"true"`
+	NativeSidecars                     bool              `default:"false" desc:"Inject NSM containers as native sidecars (init containers with restartPolicy: Always) if the API server supports them (k8s 1.29+)" split_words:"true"`
+	NativeSidecarsBeforeInitContainers bool              `default:"false" desc:"Place NSM init containers and native sidecars before the init containers of the resource" split_words:"true"`
+	SocketVolumeType                   VolumeType        `default:"auto" desc:"Type of SPIRE and NSM socket volumes: 'csi', 'hostpath' or 'auto' to use CSI volumes for namespaces with not privileged PSA level" split_words:"true"`
+	SpireSocketMountPath               string            `default:"/run/spire/sockets" desc:"Path where SPIRE agent socket directory is mounted into NSM containers" split_words:"true"`
+	SpireSocketHostPath                string            `default:"/run/spire/sockets" desc:"Host path of SPIRE agent socket directory used for hostPath volumes" split_words:"true"`
+	SpireSocketFileName                string            `default:"agent.sock" desc:"File name of SPIRE agent socket, SPIFFE_ENDPOINT_SOCKET env points to it in Config.SpireSocketMountPath" split_words:"true"`
+	SpireSocketCSIDriver               string            `default:"csi.spiffe.io" desc:"Name of SPIFFE CSI driver used for CSI volumes" split_words:"true"`
+	NSMSocketMountPath                 string            `default:"/var/lib/networkservicemesh" desc:"Path where NSM socket directory is mounted into NSM containers" split_words:"true"`
+	NSMSocketHostPath                  string            `default:"/var/lib/networkservicemesh" desc:"Host path of NSM socket directory used for hostPath volumes" split_words:"true"`
+	NSMSocketCSIDriver                 string            `default:"csi.networkservicemesh.io" desc:"Name of NSM CSI driver used for CSI volumes" split_words:"true"`
+	PodSecurityViolationPolicy         ViolationPolicy   `default:"warn" desc:"Action for resources violating the PSA enforce level of the namespace after injection: 'warn' to return an admission warning or 'deny' to reject the resource" split_words:"true"`
+	PodSecurityDefaults                map[string]string `default:"" desc:"Cluster default PSA policy for namespaces without PSA labels, e.g. 'enforce:baseline,warn:restricted,warn-version:v1.29'" split_words:"true"`
+	PodSecurityModes                   []string          `default:"enforce,warn" desc:"PSA modes whose levels are considered to choose socket volumes and security context of NSM containers: enforce, audit, warn" split_words:"true"`
+	WebhookMode                        Mode              `default:"spire" desc:"Default 'spire' mode uses spire certificates and external webhook configuration. Set to 'selfregister' to use the automatically generated webhook configuration" split_words:"true"`
+	WebhookReconcilePeriod             time.Duration     `default:"1m" desc:"Period of checking the webhook configuration registered in selfregister mode in addition to watching it, the deleted or modified configuration is restored. 0 disables the reconciliation. Without Config.CertFilePath and Config.KeyFilePath only the replica that registered the configuration last reconciles it" split_words:"true"`
+	CertFilePath                       string            `desc:"Path to certificate. Preferred use if specified" split_words:"true"`
+	KeyFilePath                        string            `desc:"Path to RSA/Ed25519 related to Config.CertFilePath. Preferred use if specified" split_words:"true"`
+	CABundleFilePath                   string            `desc:"Path to cabundle file related to Config.CertFilePath. Preferred use if specified" split_words:"true"`
+	TLSMinVersion                      TLSVersion        `default:"1.2" desc:"Minimum TLS version of the admission listener: '1.2' or '1.3'" split_words:"true"`
+	TLSCipherSuites                    []string          `desc:"IANA names of the TLS 1.2 cipher suites of the admission listener, e.g. TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, not allowed with the minimum version 1.3 (default: secure suites of Go)" split_words:"true"`
+	TLSCurvePreferences                []string          `desc:"Elliptic curves of the admission listener in preference order: X25519, P256, P384, P521 (default: curves of Go)" split_words:"true"`
+	ClientCAFilePath                   string            `desc:"Path to CA bundle verifying client certificates. If specified, /mutate requires a client certificate signed by it, e.g. of the API server" split_words:"true"`
+	OpenTelemetryEndpoint              string            `default:"otel-collector.observability.svc.cluster.local:4317" desc:"OpenTelemetry Collector Endpoint" split_words:"true"`
+	MetricsExportInterval              time.Duration     `default:"10s" desc:"interval between mertics exports" split_words:"true"`
+	SidecarLimitsMemory                string            `default:"80Mi" desc:"Lower bound of the NSM sidecar memory limit (in k8s resource management units)" split_words:"true"`
+	SidecarLimitsCPU                   string            `default:"200m" desc:"Lower bound of the NSM sidecar CPU limit (in k8s resource management units)" split_words:"true"`
+	SidecarRequestsMemory              string            `default:"40Mi" desc:"Lower bound of the NSM sidecar requests memory limits (in k8s resource management units)" split_words:"true"`
+	SidecarRequestsCPU                 string            `default:"100m" desc:"Lower bound of the NSM sidecar requests CPU limits (in k8s resource management units)" split_words:"true"`
+	ListenOn                           string            `default:":443" desc:"Address of the admission (TLS) listener serving /mutate" split_words:"true"`
+	OperationalListenOn                string            `desc:"Address of the plain HTTP listener serving /healthz, /readyz and /ready, the admission listener serves them if not specified" split_words:"true"`
+	ServicePort                        int32             `default:"443" desc:"Port of Config.ServiceName the webhook configuration points to in selfregister mode" split_words:"true"`
+	ShutdownGracePeriod                time.Duration     `default:"5s" desc:"Time between marking the webhook not ready and draining in-flight admissions on shutdown" split_words:"true"`
+	ShutdownTimeout                    time.Duration     `default:"30s" desc:"Maximum time of draining in-flight admissions and deregistering the webhook on shutdown" split_words:"true"`
+	HealthCheckTimeout                 time.Duration     `default:"1s" desc:"Timeout of the liveness and readiness checks" split_words:"true"`
+	MaxRequestBodySize                 int64             `default:"7340032" desc:"Maximum size of the AdmissionReview request body in bytes" split_words:"true"`
+	PprofEnabled                       bool              `default:"false" desc:"is pprof enabled" split_words:"true"`
+	PprofListenOn                      string            `default:"localhost:6060" desc:"pprof URL to ListenAndServe" split_words:"true"`
+	Kubeconfig                         string            `desc:"Path to kubeconfig used instead of KUBECONFIG env, ~/.kube/config and the in-cluster config, e.g. to run the webhook locally. Can be overridden by --kubeconfig flag" split_words:"true"`
+	KubeContext                        string            `desc:"Context of the kubeconfig, the current context if not specified. Can be overridden by --context flag" split_words:"true"`
 	// QPS for 50 NSC
-	KubeletQPS int `default:"50" desc:"kubelet QPS config" split_words:"true"`
-	envs       []corev1.EnvVar
-	caBundle   []byte
-	cert       tls.Certificate
-	once       sync.Once
+	KubeletQPS    int `default:"50" desc:"kubelet QPS config" split_words:"true"`
+	profiles      map[string]*Profile
+	resourceRules []*ResourceRule
+	nameTemplate  *NameTemplate
+	caBundle      []byte
+	cert          tls.Certificate
+	once          sync.Once
 }
 
 // Mode internal webhook mode type.
@@ -92,10 +135,153 @@ const (
 	SelfregisterMode
 )
 
+// VolumeType internal socket volume type.
+type VolumeType uint8
+
+// Decode takes a string volume type and returns the VolumeType constant.
+func (vt *VolumeType) Decode(volumeType string) error {
+	switch strings.ToLower(volumeType) {
+	case "auto":
+		*vt = AutoVolumeType
+		return nil
+	case "csi":
+		*vt = CSIVolumeType
+		return nil
+	case "hostpath":
+		*vt = HostPathVolumeType
+		return nil
+	}
+	return errors.Errorf("not a valid socket volume type: %s", volumeType)
+}
+
+// These are the different types of SPIRE and NSM socket volumes.
+const (
+	// AutoVolumeType uses CSI volumes for namespaces with not privileged PSA level and hostPath volumes otherwise.
+	AutoVolumeType VolumeType = iota
+	// CSIVolumeType always uses CSI volumes.
+	CSIVolumeType
+	// HostPathVolumeType always uses hostPath volumes.
+	HostPathVolumeType
+)
+
+// ViolationPolicy internal pod security violation policy type.
+type ViolationPolicy uint8
+
+// Decode takes a string violation policy and returns the ViolationPolicy constant.
+func (vp *ViolationPolicy) Decode(policy string) error {
+	switch strings.ToLower(policy) {
+	case "warn":
+		*vp = WarnViolationPolicy
+		return nil
+	case "deny":
+		*vp = DenyViolationPolicy
+		return nil
+	}
+	return errors.Errorf("not a valid pod security violation policy: %s", policy)
+}
+
+// These are the different actions for resources violating the pod security level.
+const (
+	// WarnViolationPolicy admits the resource and returns an explanatory admission warning.
+	WarnViolationPolicy ViolationPolicy = iota
+	// DenyViolationPolicy rejects the resource with an explanatory message.
+	DenyViolationPolicy
+)
+
+// MergeStrategy internal NS annotation merge strategy type.
+type MergeStrategy uint8
+
+// Decode takes a string merge strategy and returns the MergeStrategy constant.
+func (ms *MergeStrategy) Decode(strategy string) error {
+	switch strings.ToLower(strategy) {
+	case "override":
+		*ms = OverrideMergeStrategy
+		return nil
+	case "union":
+		*ms = UnionMergeStrategy
+		return nil
+	case "namespace-only":
+		*ms = NamespaceOnlyMergeStrategy
+		return nil
+	}
+	return errors.Errorf("not a valid annotation merge strategy: %s", strategy)
+}
+
+// These are the different ways to combine the NS annotations of the namespace and the resource.
+const (
+	// OverrideMergeStrategy uses the annotation of the resource if present, otherwise the annotation of the namespace.
+	OverrideMergeStrategy MergeStrategy = iota
+	// UnionMergeStrategy requests the network services of both annotations, the resource wins for the same interface name.
+	UnionMergeStrategy
+	// NamespaceOnlyMergeStrategy uses the annotation of the namespace and ignores the annotation of the resource.
+	NamespaceOnlyMergeStrategy
+)
+
+// NameStrategy internal NSM_NAME strategy type.
+type NameStrategy uint8
+
+// Decode takes a string name strategy and returns the NameStrategy constant.
+func (ns *NameStrategy) Decode(strategy string) error {
+	switch strings.ToLower(strategy) {
+	case "hash":
+		*ns = HashNameStrategy
+		return nil
+	case "random":
+		*ns = RandomNameStrategy
+		return nil
+	case "pod-name":
+		*ns = PodNameStrategy
+		return nil
+	case "template":
+		*ns = TemplateNameStrategy
+		return nil
+	}
+	return errors.Errorf("not a valid name strategy: %s", strategy)
+}
+
+// These are the different ways to compute NSM_NAME env of NSM containers for pods without generateName.
+const (
+	// HashNameStrategy appends a hash of the resource kind, namespace, name and UID to the pod name, stable for identical inputs.
+	HashNameStrategy NameStrategy = iota
+	// RandomNameStrategy appends a random UUID to the pod name.
+	RandomNameStrategy
+	// PodNameStrategy uses the pod name only.
+	PodNameStrategy
+	// TemplateNameStrategy renders Config.NameTemplate.
+	TemplateNameStrategy
+)
+
+// SpiffeEndpointSocket returns the SPIRE agent socket address inside NSM containers.
+func (c *Config) SpiffeEndpointSocket() string {
+	return "unix://" + path.Join(c.SpireSocketMountPath, c.SpireSocketFileName)
+}
+
 // GetOrResolveEnvs converts on the first call passed Config.Envs into []corev1.EnvVar or returns parsed values.
 func (c *Config) GetOrResolveEnvs() []corev1.EnvVar {
 	c.once.Do(c.initialize)
-	return c.envs
+	return c.profiles[DefaultProfileName].GetEnvs()
+}
+
+// GetOrResolveProfile returns the injection profile by name. Empty name means the default profile built from Config values.
+func (c *Config) GetOrResolveProfile(name string) (*Profile, bool) {
+	c.once.Do(c.initialize)
+	if name == "" {
+		name = DefaultProfileName
+	}
+	p, ok := c.profiles[name]
+	return p, ok
+}
+
+// GetOrResolveNameTemplate returns parsed Config.NameTemplate, nil if it's not specified.
+func (c *Config) GetOrResolveNameTemplate() *NameTemplate {
+	c.once.Do(c.initialize)
+	return c.nameTemplate
+}
+
+// GetOrResolveResourceRules parses on the first call passed Config.ResourceRulesFilePath or returns parsed rules.
+func (c *Config) GetOrResolveResourceRules() []*ResourceRule {
+	c.once.Do(c.initialize)
+	return c.resourceRules
 }
 
 // GetOrResolveCABundle tries to lookup CA bundle from passed Config.CABundleFilePath or returns ca bundle from self signed in memory certificate.
@@ -115,24 +301,102 @@ func (c *Config) IsExistingCertificatesUsed() bool {
 	return c.CertFilePath != "" && c.KeyFilePath != ""
 }
 
+// IsWebhookReconciliationEnabled checks whether the webhook configuration registered in selfregister mode is reconciled.
+// With the self signed in memory certificates only the replica owning the configuration reconciles it, see IsExistingCertificatesUsed.
+func (c *Config) IsWebhookReconciliationEnabled() bool {
+	return c.WebhookMode == SelfregisterMode && c.WebhookReconcilePeriod != 0
+}
+
 func (c *Config) initialize() {
-	c.initializeEnvs()
+	c.initializeProfiles()
+	c.initializeResourceRules()
+	c.initializeNameTemplate()
 	c.initializeCert()
 	c.initializeCABundle()
 }
 
-func (c *Config) initializeEnvs() {
-	for _, envRaw := range c.Envs {
-		kv := strings.Split(envRaw, "=")
-		c.envs = append(c.envs, corev1.EnvVar{
-			Name:  kv[0],
-			Value: kv[1],
-		})
+// newDefaultProfile builds the default profile from the Config values.
+func (c *Config) newDefaultProfile() (*Profile, error) {
+	envVars, err := LoadEnvs(c.EnvsFilePath)
+	if err != nil {
+		return nil, err
+	}
+	return &Profile{
+		Name:                           DefaultProfileName,
+		Labels:                         c.Labels,
+		InitContainerImages:            c.InitContainerImages,
+		ContainerImages:                c.ContainerImages,
+		InitContainerTemplatesFilePath: c.InitContainerTemplatesFilePath,
+		ContainerTemplatesFilePath:     c.ContainerTemplatesFilePath,
+		Envs:                           c.Envs,
+		EnvVars:                        envVars,
+		SidecarLimitsMemory:            c.SidecarLimitsMemory,
+		SidecarLimitsCPU:               c.SidecarLimitsCPU,
+		SidecarRequestsMemory:          c.SidecarRequestsMemory,
+		SidecarRequestsCPU:             c.SidecarRequestsCPU,
+	}, nil
+}
+
+func (c *Config) initializeProfiles() {
+	defaultProfile, err := c.newDefaultProfile()
+	if err != nil {
+		panic(err.Error())
+	}
+	if err := defaultProfile.resolve(c, nil); err != nil {
+		panic(err.Error())
+	}
+	c.profiles = map[string]*Profile{
+		DefaultProfileName: defaultProfile,
+	}
+
+	profiles, err := LoadProfiles(c.ProfilesFilePath)
+	if err != nil {
+		panic(err.Error())
+	}
+	for _, p := range profiles {
+		if p.Name == "" {
+			panic(fmt.Sprintf("profile name is not specified in %s", c.ProfilesFilePath))
+		}
+		if _, ok := c.profiles[p.Name]; ok {
+			panic(fmt.Sprintf("duplicated profile %s in %s", p.Name, c.ProfilesFilePath))
+		}
+		if err := p.resolve(c, defaultProfile); err != nil {
+			panic(err.Error())
+		}
+		c.profiles[p.Name] = p
+	}
+}
+
+func (c *Config) initializeResourceRules() {
+	rules, err := LoadResourceRules(c.ResourceRulesFilePath)
+	if err != nil {
+		panic(err.Error())
+	}
+	c.resourceRules = rules
+}
+
+func (c *Config) initializeNameTemplate() {
+	tmpl, err := ParseNameTemplate(c.NameTemplate)
+	if err != nil {
+		panic(err.Error())
+	}
+	c.nameTemplate = tmpl
+}
+
+// parseEnvs parses the envs in the extended syntax followed by the structured envs and the envs required by NSM containers.
+func (c *Config) parseEnvs(rawEnvs []string, envVars []corev1.EnvVar) ([]corev1.EnvVar, error) {
+	envs, err := ParseEnvs(rawEnvs)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateEnvs(envVars); err != nil {
+		return nil, err
 	}
-	c.envs = append(c.envs,
+	envs = append(envs, envVars...)
+	return append(envs,
 		corev1.EnvVar{
 			Name:  "SPIFFE_ENDPOINT_SOCKET",
-			Value: "unix:///run/spire/sockets/agent.sock",
+			Value: c.SpiffeEndpointSocket(),
 		},
 		corev1.EnvVar{
 			Name: "POD_NAME",
@@ -142,7 +406,7 @@ func (c *Config) initializeEnvs() {
 				},
 			},
 		},
-	)
+	), nil
 }
 
 func (c *Config) initializeCABundle() {
@@ -0,0 +1,43 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package configtest provides the configuration fixture of the tests.
+package configtest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/config"
+)
+
+// ContainerImage is the image of the injected container of the configuration returned by New.
+const ContainerImage = "ghcr.io/networkservicemesh/cmd-nsc:latest"
+
+// New returns the configuration processed from NSM_* envs, e.g. set by t.Setenv, with NSM_CONTAINER_IMAGES
+// defaulted to ContainerImage.
+func New(t testing.TB) *config.Config {
+	t.Helper()
+	if _, ok := os.LookupEnv("NSM_CONTAINER_IMAGES"); !ok {
+		t.Setenv("NSM_CONTAINER_IMAGES", ContainerImage)
+	}
+	conf := new(config.Config)
+	require.NoError(t, envconfig.Process("nsm", conf))
+	return conf
+}
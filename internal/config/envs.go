@@ -0,0 +1,213 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// These are the sources of env values supported by the extended syntax NAME@source=reference.
+const (
+	configMapKeyRefSource  = "configMapKeyRef"
+	secretKeyRefSource     = "secretKeyRef"
+	fieldRefSource         = "fieldRef"
+	resourceFieldRefSource = "resourceFieldRef"
+)
+
+// ParseEnvs parses entries of Config.Envs. Each entry is one of:
+//
+//	NAME=value                                      - the value may contain '=', '\,' and '\\' escape ',' and '\'
+//	NAME@configMapKeyRef=<configmap>/<key>
+//	NAME@secretKeyRef=<secret>/<key>
+//	NAME@fieldRef=<field path>
+//	NAME@resourceFieldRef=[<container>:]<resource>[/<divisor>]
+//
+// The entries are split by envconfig on every comma, so the entries ending with an escaping '\' are joined back.
+func ParseEnvs(rawEnvs []string) ([]corev1.EnvVar, error) {
+	var envs []corev1.EnvVar
+	for _, entry := range joinEscaped(rawEnvs) {
+		env, err := parseEnv(entry)
+		if err != nil {
+			return nil, err
+		}
+		envs = append(envs, env)
+	}
+	return envs, nil
+}
+
+// LoadEnvs reads a YAML/JSON list of corev1.EnvVar from the passed file. Returns nil if path is empty.
+func LoadEnvs(path string) ([]corev1.EnvVar, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path) // #nosec
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read envs from %s", path)
+	}
+	defer func() { _ = f.Close() }()
+
+	var envs []corev1.EnvVar
+	if err := yaml.NewYAMLOrJSONDecoder(f, 4096).Decode(&envs); err != nil && err != io.EOF {
+		return nil, errors.Wrapf(err, "failed to decode envs from %s", path)
+	}
+	if err := ValidateEnvs(envs); err != nil {
+		return nil, errors.Wrapf(err, "invalid envs in %s", path)
+	}
+	return envs, nil
+}
+
+// ValidateEnvs checks names of the envs and that each env has either a value or exactly one value source.
+func ValidateEnvs(envs []corev1.EnvVar) error {
+	for i := range envs {
+		env := &envs[i]
+		if errs := validation.IsEnvVarName(env.Name); len(errs) > 0 {
+			return errors.Errorf("not a valid env name %q: %s", env.Name, strings.Join(errs, "; "))
+		}
+		if env.ValueFrom == nil {
+			continue
+		}
+		if env.Value != "" {
+			return errors.Errorf("env %s: value and valueFrom are mutually exclusive", env.Name)
+		}
+		sources := 0
+		for _, set := range []bool{env.ValueFrom.ConfigMapKeyRef != nil, env.ValueFrom.SecretKeyRef != nil,
+			env.ValueFrom.FieldRef != nil, env.ValueFrom.ResourceFieldRef != nil} {
+			if set {
+				sources++
+			}
+		}
+		if sources != 1 {
+			return errors.Errorf("env %s: valueFrom must have exactly one source", env.Name)
+		}
+	}
+	return nil
+}
+
+func joinEscaped(rawEnvs []string) []string {
+	var entries []string
+	var current string
+	joining := false
+	for _, raw := range rawEnvs {
+		if joining {
+			current += "," + raw
+		} else {
+			current = raw
+		}
+		joining = isEscaped(current)
+		if !joining {
+			entries = append(entries, current)
+		}
+	}
+	if joining {
+		entries = append(entries, current)
+	}
+	return entries
+}
+
+// isEscaped checks whether the entry ends with an odd number of '\', i.e. the following comma is escaped.
+func isEscaped(entry string) bool {
+	return (len(entry)-len(strings.TrimRight(entry, `\`)))%2 == 1
+}
+
+func unescape(value string) string {
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) && (value[i+1] == '\\' || value[i+1] == ',') {
+			i++
+		}
+		sb.WriteByte(value[i])
+	}
+	return sb.String()
+}
+
+func parseEnv(entry string) (corev1.EnvVar, error) {
+	key, value, ok := strings.Cut(entry, "=")
+	if !ok {
+		return corev1.EnvVar{}, errors.Errorf("not a valid env %q: expected NAME=value", entry)
+	}
+	name, source, hasSource := strings.Cut(key, "@")
+	env := corev1.EnvVar{Name: name}
+	if !hasSource {
+		env.Value = unescape(value)
+	} else {
+		valueFrom, err := parseValueFrom(source, unescape(value))
+		if err != nil {
+			return corev1.EnvVar{}, errors.Wrapf(err, "not a valid env %q", entry)
+		}
+		env.ValueFrom = valueFrom
+	}
+	if err := ValidateEnvs([]corev1.EnvVar{env}); err != nil {
+		return corev1.EnvVar{}, err
+	}
+	return env, nil
+}
+
+func parseValueFrom(source, ref string) (*corev1.EnvVarSource, error) {
+	switch source {
+	case configMapKeyRefSource, secretKeyRefSource:
+		name, key, ok := strings.Cut(ref, "/")
+		if !ok || name == "" || key == "" {
+			return nil, errors.Errorf("%s must be <name>/<key>", source)
+		}
+		if source == configMapKeyRefSource {
+			return &corev1.EnvVarSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: name},
+				Key:                  key,
+			}}, nil
+		}
+		return &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: name},
+			Key:                  key,
+		}}, nil
+	case fieldRefSource:
+		if ref == "" {
+			return nil, errors.New("fieldRef must be a field path")
+		}
+		return &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: ref}}, nil
+	case resourceFieldRefSource:
+		return parseResourceFieldRef(ref)
+	}
+	return nil, errors.Errorf("unknown value source %s", source)
+}
+
+func parseResourceFieldRef(ref string) (*corev1.EnvVarSource, error) {
+	selector := &corev1.ResourceFieldSelector{}
+	if container, rest, ok := strings.Cut(ref, ":"); ok {
+		selector.ContainerName, ref = container, rest
+	}
+	ref, divisor, hasDivisor := strings.Cut(ref, "/")
+	if ref == "" {
+		return nil, errors.New("resourceFieldRef must be [<container>:]<resource>[/<divisor>]")
+	}
+	selector.Resource = ref
+	if hasDivisor {
+		q, err := resource.ParseQuantity(divisor)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid divisor %s", divisor)
+		}
+		selector.Divisor = q
+	}
+	return &corev1.EnvVarSource{ResourceFieldRef: selector}, nil
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/config"
+)
+
+func TestParseEnvs(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		// raw are the entries as split by envconfig on every comma
+		raw  []string
+		envs []corev1.EnvVar
+	}{
+		{
+			name: "values",
+			raw:  []string{"A=1", "B=x=y", "C="},
+			envs: []corev1.EnvVar{{Name: "A", Value: "1"}, {Name: "B", Value: "x=y"}, {Name: "C"}},
+		},
+		{
+			name: "escaped commas are joined back",
+			raw:  []string{`A=a\`, `b\`, "c", "B=d"},
+			envs: []corev1.EnvVar{{Name: "A", Value: "a,b,c"}, {Name: "B", Value: "d"}},
+		},
+		{
+			name: "escaped backslash before comma",
+			raw:  []string{`A=a\\`, "B=b"},
+			envs: []corev1.EnvVar{{Name: "A", Value: `a\`}, {Name: "B", Value: "b"}},
+		},
+		{
+			name: "trailing escaped comma",
+			raw:  []string{`A=a\`},
+			envs: []corev1.EnvVar{{Name: "A", Value: `a\`}},
+		},
+		{
+			name: "value sources",
+			raw: []string{
+				"A@configMapKeyRef=cm/key",
+				"B@secretKeyRef=secret/key",
+				"C@fieldRef=spec.nodeName",
+				"D@resourceFieldRef=limits.memory",
+				"E@resourceFieldRef=app:requests.cpu/1m",
+			},
+			envs: []corev1.EnvVar{
+				{Name: "A", ValueFrom: &corev1.EnvVarSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "cm"}, Key: "key"}}},
+				{Name: "B", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "secret"}, Key: "key"}}},
+				{Name: "C", ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "spec.nodeName"}}},
+				{Name: "D", ValueFrom: &corev1.EnvVarSource{ResourceFieldRef: &corev1.ResourceFieldSelector{Resource: "limits.memory"}}},
+				{Name: "E", ValueFrom: &corev1.EnvVarSource{ResourceFieldRef: &corev1.ResourceFieldSelector{
+					ContainerName: "app", Resource: "requests.cpu", Divisor: resource.MustParse("1m")}}},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			envs, err := config.ParseEnvs(tc.raw)
+			require.NoError(t, err)
+			require.Equal(t, tc.envs, envs)
+		})
+	}
+}
+
+func TestParseEnvs_Invalid(t *testing.T) {
+	for name, raw := range map[string]string{
+		"no value":               "A",
+		"invalid name":           "1A=1",
+		"unknown source":         "A@fileRef=x",
+		"key ref without key":    "A@configMapKeyRef=cm",
+		"key ref without name":   "A@secretKeyRef=/key",
+		"empty field ref":        "A@fieldRef=",
+		"empty resource":         "A@resourceFieldRef=app:",
+		"invalid divisor":        "A@resourceFieldRef=limits.cpu/x",
+		"invalid name of source": "1A@fieldRef=spec.nodeName",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := config.ParseEnvs([]string{raw})
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestLoadEnvs(t *testing.T) {
+	envs, err := config.LoadEnvs("")
+	require.NoError(t, err)
+	require.Nil(t, envs)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "envs.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+- name: A
+  value: "a,b"
+- name: B
+  valueFrom:
+    fieldRef:
+      fieldPath: metadata.namespace
+`), 0o600))
+	envs, err = config.LoadEnvs(path)
+	require.NoError(t, err)
+	require.Equal(t, []corev1.EnvVar{
+		{Name: "A", Value: "a,b"},
+		{Name: "B", ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.namespace"}}},
+	}, envs)
+
+	for name, content := range map[string]string{
+		"value and valueFrom": "- {name: A, value: a, valueFrom: {fieldRef: {fieldPath: metadata.name}}}",
+		"two sources":         "- {name: A, valueFrom: {fieldRef: {fieldPath: metadata.name}, secretKeyRef: {name: s, key: k}}}",
+		"invalid name":        "- {name: 1A, value: a}",
+		"not a list":          "name: A",
+	} {
+		t.Run(name, func(t *testing.T) {
+			invalid := filepath.Join(dir, "invalid.yaml")
+			require.NoError(t, os.WriteFile(invalid, []byte(content), 0o600))
+			_, err := config.LoadEnvs(invalid)
+			require.Error(t, err)
+		})
+	}
+}
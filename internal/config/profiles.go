@@ -0,0 +1,111 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// DefaultProfileName is the name of the profile built from the global Config values.
+const DefaultProfileName = "default"
+
+// Profile is a named set of containers, envs, resources, volumes and labels injected into a workload.
+type Profile struct {
+	Name                           string               `json:"name"`
+	Labels                         map[string]string    `json:"labels,omitempty"`
+	InitContainerImages            []string             `json:"initContainerImages,omitempty"`
+	ContainerImages                []string             `json:"containerImages,omitempty"`
+	InitContainerTemplatesFilePath string               `json:"initContainerTemplatesFilePath,omitempty"`
+	ContainerTemplatesFilePath     string               `json:"containerTemplatesFilePath,omitempty"`
+	Envs                           []string             `json:"envs,omitempty"`
+	EnvVars                        []corev1.EnvVar      `json:"envVars,omitempty"`
+	Volumes                        []corev1.Volume      `json:"volumes,omitempty"`
+	VolumeMounts                   []corev1.VolumeMount `json:"volumeMounts,omitempty"`
+	SidecarLimitsMemory            string               `json:"sidecarLimitsMemory,omitempty"`
+	SidecarLimitsCPU               string               `json:"sidecarLimitsCPU,omitempty"`
+	SidecarRequestsMemory          string               `json:"sidecarRequestsMemory,omitempty"`
+	SidecarRequestsCPU             string               `json:"sidecarRequestsCPU,omitempty"`
+	envs                           []corev1.EnvVar
+	initContainerTemplates         *ContainerTemplates
+	containerTemplates             *ContainerTemplates
+}
+
+// GetEnvs returns parsed Profile.Envs and Profile.EnvVars followed by the envs required by NSM containers.
+func (p *Profile) GetEnvs() []corev1.EnvVar {
+	return p.envs
+}
+
+// GetInitContainerTemplates returns parsed templates from Profile.InitContainerTemplatesFilePath.
+func (p *Profile) GetInitContainerTemplates() *ContainerTemplates {
+	return p.initContainerTemplates
+}
+
+// GetContainerTemplates returns parsed templates from Profile.ContainerTemplatesFilePath.
+func (p *Profile) GetContainerTemplates() *ContainerTemplates {
+	return p.containerTemplates
+}
+
+// resolve parses envs and templates of the profile and inherits not specified resources from the parent profile.
+func (p *Profile) resolve(c *Config, parent *Profile) error {
+	if parent != nil {
+		p.SidecarLimitsMemory = valueOrDefault(p.SidecarLimitsMemory, parent.SidecarLimitsMemory)
+		p.SidecarLimitsCPU = valueOrDefault(p.SidecarLimitsCPU, parent.SidecarLimitsCPU)
+		p.SidecarRequestsMemory = valueOrDefault(p.SidecarRequestsMemory, parent.SidecarRequestsMemory)
+		p.SidecarRequestsCPU = valueOrDefault(p.SidecarRequestsCPU, parent.SidecarRequestsCPU)
+	}
+	var err error
+	if p.envs, err = c.parseEnvs(p.Envs, p.EnvVars); err != nil {
+		return errors.Wrapf(err, "profile %s", p.Name)
+	}
+	if p.initContainerTemplates, err = LoadContainerTemplates(p.InitContainerTemplatesFilePath); err != nil {
+		return errors.Wrapf(err, "profile %s", p.Name)
+	}
+	if p.containerTemplates, err = LoadContainerTemplates(p.ContainerTemplatesFilePath); err != nil {
+		return errors.Wrapf(err, "profile %s", p.Name)
+	}
+	return nil
+}
+
+// LoadProfiles reads a YAML/JSON list of profiles from the passed file. Returns nil if path is empty.
+func LoadProfiles(path string) ([]*Profile, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path) // #nosec
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read profiles from %s", path)
+	}
+	defer func() { _ = f.Close() }()
+
+	var profiles []*Profile
+	if err := yaml.NewYAMLOrJSONDecoder(f, 4096).Decode(&profiles); err != nil && err != io.EOF {
+		return nil, errors.Wrapf(err, "failed to decode profiles from %s", path)
+	}
+	return profiles, nil
+}
+
+func valueOrDefault(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/config"
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/config/configtest"
+)
+
+func TestConfig_GetOrResolveProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+- name: sriov
+  containerImages: [ghcr.io/networkservicemesh/cmd-nsc:latest]
+  envs: [NSM_LOG_LEVEL=DEBUG]
+  sidecarRequestsMemory: 60Mi
+`), 0o600))
+	t.Setenv("NSM_PROFILES_FILE_PATH", path)
+	t.Setenv("NSM_ENVS", "NSM_LOG_LEVEL=INFO")
+	conf := configtest.New(t)
+	require.NoError(t, conf.Validate())
+
+	defaultProfile, ok := conf.GetOrResolveProfile("")
+	require.True(t, ok)
+	require.Equal(t, config.DefaultProfileName, defaultProfile.Name)
+	require.Equal(t, "INFO", defaultProfile.GetEnvs()[0].Value)
+
+	profile, ok := conf.GetOrResolveProfile("sriov")
+	require.True(t, ok)
+	require.Equal(t, "DEBUG", profile.GetEnvs()[0].Value)
+	require.Equal(t, "60Mi", profile.SidecarRequestsMemory)
+	require.Equal(t, defaultProfile.SidecarRequestsCPU, profile.SidecarRequestsCPU)
+	require.Equal(t, defaultProfile.SidecarLimitsMemory, profile.SidecarLimitsMemory)
+
+	_, ok = conf.GetOrResolveProfile("vpp")
+	require.False(t, ok)
+}
+
+func TestConfig_Validate_Profiles(t *testing.T) {
+	for name, test := range map[string]struct {
+		profiles string
+		problem  string
+	}{
+		"no name": {
+			profiles: `[{containerImages: [cmd-nsc]}]`,
+			problem:  "profile name is not specified",
+		},
+		"duplicated": {
+			profiles: `[{name: vpp, containerImages: [cmd-nsc]}, {name: vpp, containerImages: [cmd-nsc]}]`,
+			problem:  "duplicated profile vpp",
+		},
+		"default": {
+			profiles: `[{name: default, containerImages: [cmd-nsc]}]`,
+			problem:  "duplicated profile default",
+		},
+		"no containers": {
+			profiles: `[{name: vpp, containerImages: []}]`,
+			problem:  "profile vpp: no containers to inject",
+		},
+		"invalid image": {
+			profiles: `[{name: vpp, containerImages: [Cmd-nsc]}]`,
+			problem:  `profile vpp: not a valid image reference "Cmd-nsc"`,
+		},
+		"invalid label": {
+			profiles: `[{name: vpp, containerImages: [cmd-nsc], labels: {nsm/flavour/vpp: "true"}}]`,
+			problem:  `profile vpp: not a valid label key "nsm/flavour/vpp"`,
+		},
+		"invalid env": {
+			profiles: `[{name: vpp, containerImages: [cmd-nsc], envs: ["1NSM=value"]}]`,
+			problem:  `profile vpp: not a valid env name "1NSM"`,
+		},
+		"requests exceeding limits": {
+			profiles: `[{name: vpp, containerImages: [cmd-nsc], sidecarRequestsMemory: 100Mi}]`,
+			problem:  "profile vpp: sidecar memory requests 100Mi exceed limits 80Mi",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "profiles.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(test.profiles), 0o600))
+			t.Setenv("NSM_PROFILES_FILE_PATH", path)
+			require.ErrorContains(t, configtest.New(t).Validate(), test.problem)
+		})
+	}
+}
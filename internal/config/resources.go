@@ -0,0 +1,151 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/networkservicemesh/sdk/pkg/tools/nsurl"
+)
+
+// These are the containers the resources of ResourceRule are added to.
+const (
+	// AutoResourceTarget adds the resources to every NSM init container, or to the first NSM container if there are no NSM init containers.
+	AutoResourceTarget = ""
+	// InitContainersResourceTarget adds the resources to every NSM init container.
+	InitContainersResourceTarget = "initContainers"
+	// ContainersResourceTarget adds the resources to the first NSM container.
+	ContainersResourceTarget = "containers"
+	// AllResourceTarget adds the resources to every NSM init container and to the first NSM container.
+	AllResourceTarget = "all"
+)
+
+// ResourceRule maps a network service requested by the NS annotation to resources of NSM containers.
+// The rule matches an NS URL having the Label and the network service name matching NetworkService, at least one of them must be set.
+type ResourceRule struct {
+	// Label is the name of the NS URL label that must be present.
+	Label string `json:"label,omitempty"`
+	// NetworkService is a regular expression that must match the whole network service name.
+	NetworkService string `json:"networkService,omitempty"`
+	// ResourceName is the name of the requested resource. If empty, each comma-separated value of the Label is the name of the resource.
+	ResourceName corev1.ResourceName `json:"resourceName,omitempty"`
+	// Quantity is requested for each matched URL or Label value, "1" by default.
+	Quantity string `json:"quantity,omitempty"`
+	// Target selects NSM containers the resources are added to: "initContainers", "containers", "all" or empty for auto.
+	Target string `json:"target,omitempty"`
+
+	networkService *regexp.Regexp
+	quantity       resource.Quantity
+}
+
+// DefaultResourceRules requests a device of the device plugin resource named by each sriovToken label.
+func DefaultResourceRules() []*ResourceRule {
+	rule := &ResourceRule{Label: "sriovToken"}
+	if err := rule.resolve(); err != nil {
+		panic(err.Error())
+	}
+	return []*ResourceRule{rule}
+}
+
+// LoadResourceRules reads a YAML/JSON list of resource rules from the passed file. Returns DefaultResourceRules if path is empty.
+func LoadResourceRules(path string) ([]*ResourceRule, error) {
+	if path == "" {
+		return DefaultResourceRules(), nil
+	}
+	f, err := os.Open(path) // #nosec
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read resource rules from %s", path)
+	}
+	defer func() { _ = f.Close() }()
+
+	var rules []*ResourceRule
+	if err := yaml.NewYAMLOrJSONDecoder(f, 4096).Decode(&rules); err != nil && err != io.EOF {
+		return nil, errors.Wrapf(err, "failed to decode resource rules from %s", path)
+	}
+	for i, rule := range rules {
+		if err := rule.resolve(); err != nil {
+			return nil, errors.Wrapf(err, "invalid resource rule %d in %s", i, path)
+		}
+	}
+	return rules, nil
+}
+
+func (r *ResourceRule) resolve() error {
+	if r.Label == "" && r.NetworkService == "" {
+		return errors.New("label or networkService must be specified")
+	}
+	if r.Label == "" && r.ResourceName == "" {
+		return errors.New("resourceName must be specified for rules without label")
+	}
+	switch r.Target {
+	case AutoResourceTarget, InitContainersResourceTarget, ContainersResourceTarget, AllResourceTarget:
+	default:
+		return errors.Errorf("not a valid target: %s", r.Target)
+	}
+	if r.NetworkService != "" {
+		re, err := regexp.Compile("^(?:" + r.NetworkService + ")$")
+		if err != nil {
+			return errors.Wrapf(err, "invalid networkService %s", r.NetworkService)
+		}
+		r.networkService = re
+	}
+	quantity, err := resource.ParseQuantity(valueOrDefault(r.Quantity, "1"))
+	if err != nil {
+		return errors.Wrapf(err, "invalid quantity %s", r.Quantity)
+	}
+	r.quantity = quantity
+	return nil
+}
+
+// Resources returns the resources requested by the NS URL, empty if the rule doesn't match it.
+func (r *ResourceRule) Resources(u *nsurl.NSURL) corev1.ResourceList {
+	if r.networkService != nil && !r.networkService.MatchString(u.NetworkService()) {
+		return nil
+	}
+	if r.Label == "" {
+		return corev1.ResourceList{r.ResourceName: r.quantity.DeepCopy()}
+	}
+	values, ok := (*url.URL)(u).Query()[r.Label]
+	if !ok {
+		return nil
+	}
+	if r.ResourceName != "" {
+		return corev1.ResourceList{r.ResourceName: r.quantity.DeepCopy()}
+	}
+	result := make(corev1.ResourceList)
+	for _, value := range values {
+		// a value may list several resources separated by commas, e.g. sriovToken=intel/10G,intel/25G of the structured NS annotation
+		for _, name := range strings.Split(value, ",") {
+			if name == "" {
+				continue
+			}
+			q := result[corev1.ResourceName(name)]
+			q.Add(r.quantity)
+			result[corev1.ResourceName(name)] = q
+		}
+	}
+	return result
+}
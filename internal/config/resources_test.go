@@ -0,0 +1,130 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config_test
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/config"
+	"github.com/networkservicemesh/sdk/pkg/tools/nsurl"
+)
+
+func loadResourceRules(t *testing.T, rules string) ([]*config.ResourceRule, error) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(rules), 0o600))
+	return config.LoadResourceRules(path)
+}
+
+func TestLoadResourceRules_Invalid(t *testing.T) {
+	for name, test := range map[string]struct {
+		rules string
+		err   string
+	}{
+		"no label and network service": {
+			rules: `[{resourceName: hugepages-2Mi}]`,
+			err:   "label or networkService must be specified",
+		},
+		"no label and resource name": {
+			rules: `[{networkService: vpp-.*}]`,
+			err:   "resourceName must be specified for rules without label",
+		},
+		"target": {
+			rules: `[{label: sriovToken, target: sidecars}]`,
+			err:   "not a valid target: sidecars",
+		},
+		"network service": {
+			rules: `[{networkService: "vpp-(", resourceName: hugepages-2Mi}]`,
+			err:   "invalid networkService vpp-(",
+		},
+		"quantity": {
+			rules: `[{label: sriovToken}, {label: mlx, quantity: one}]`,
+			err:   "invalid resource rule 1",
+		},
+		"not a list": {
+			rules: `{label: sriovToken}`,
+			err:   "failed to decode resource rules",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := loadResourceRules(t, test.rules)
+			require.ErrorContains(t, err, test.err)
+		})
+	}
+	_, err := config.LoadResourceRules("/nonexistent/rules.yaml")
+	require.ErrorContains(t, err, "failed to read resource rules from /nonexistent/rules.yaml")
+}
+
+func TestResourceRule_Resources(t *testing.T) {
+	rules, err := loadResourceRules(t, `
+- label: sriovToken
+- label: mlx
+  resourceName: nvidia.com/mlnx_sriov_rdma
+  quantity: "2"
+- networkService: vpp-.*
+  resourceName: hugepages-2Mi
+  quantity: 512Mi
+  target: containers
+- label: sriovToken
+  networkService: smartnic
+  resourceName: example.com/vf
+`)
+	require.NoError(t, err)
+	for nsURL, expected := range map[string][]corev1.ResourceList{
+		"kernel://ns/nsm-1": {nil, nil, nil, nil},
+		"kernel://ns/nsm-1?sriovToken=intel/10G": {
+			{"intel/10G": resource.MustParse("1")}, nil, nil, nil,
+		},
+		"kernel://ns/nsm-1?sriovToken=intel/10G,intel/25G&sriovToken=intel/10G": {
+			{"intel/10G": resource.MustParse("2"), "intel/25G": resource.MustParse("1")}, nil, nil, nil,
+		},
+		"kernel://ns/nsm-1?mlx=shared": {
+			nil, {"nvidia.com/mlnx_sriov_rdma": resource.MustParse("2")}, nil, nil,
+		},
+		"memif://vpp-ns/nsm-1": {
+			nil, nil, {"hugepages-2Mi": resource.MustParse("512Mi")}, nil,
+		},
+		"memif://vpp/nsm-1": {nil, nil, nil, nil},
+		"kernel://smartnic/nsm-1?sriovToken=": {
+			{}, nil, nil, {"example.com/vf": resource.MustParse("1")},
+		},
+	} {
+		u, err := url.Parse(nsURL)
+		require.NoError(t, err)
+		for i, rule := range rules {
+			actual := rule.Resources((*nsurl.NSURL)(u))
+			require.Len(t, actual, len(expected[i]), "%s: rule %d", nsURL, i)
+			for name, quantity := range expected[i] {
+				require.Zero(t, quantity.Cmp(actual[name]), "%s: rule %d: %s", nsURL, i, name)
+			}
+		}
+	}
+}
+
+func TestLoadResourceRules_Default(t *testing.T) {
+	rules, err := config.LoadResourceRules("")
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, "sriovToken", rules[0].Label)
+	require.Equal(t, config.AutoResourceTarget, rules[0].Target)
+}
@@ -0,0 +1,260 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// TemplateData is passed to ContainerTemplates on rendering.
+type TemplateData struct {
+	// PodName is the name of the pod, may be empty for workload templates and pods using generateName.
+	PodName string
+	// Namespace is the namespace of the admitted resource.
+	Namespace string
+	// Annotation is the value of Config.Annotation of the admitted resource.
+	Annotation string
+	// Envs contains values of computed envs that are added to each injected container.
+	Envs map[string]string
+}
+
+// templateFuncs are the functions of container templates. The values of TemplateData may be controlled by the users,
+// e.g. the annotation, so they must be quoted to be safely pasted into YAML: "{{ .Annotation }}" would break
+// the template or inject fields of the container, {{ .Annotation | quote }} can't. See checkQuoted.
+var templateFuncs = template.FuncMap{
+	"quote":  quote,
+	"toJson": toJSON,
+}
+
+// userFields are the fields of TemplateData controlled by the users. The name of the pod isn't validated yet
+// on admission.
+var userFields = map[string]bool{
+	"PodName":    true,
+	"Annotation": true,
+	"Envs":       true,
+}
+
+// quote returns the value as a JSON string, it's a double-quoted YAML scalar as well.
+func quote(v string) (string, error) {
+	return toJSON(v)
+}
+
+// toJSON returns the value in JSON, it's a YAML flow node as well.
+func toJSON(v interface{}) (string, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+// ContainerTemplates is a parsed list of corev1.Container templates with Go-template placeholders.
+type ContainerTemplates struct {
+	tmpl *template.Template
+}
+
+// LoadContainerTemplates reads and parses container templates from the passed file. Returns nil if path is empty.
+func LoadContainerTemplates(path string) (*ContainerTemplates, error) {
+	if path == "" {
+		return nil, nil
+	}
+	raw, err := os.ReadFile(path) // #nosec
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read container templates from %s", path)
+	}
+	tmpl, err := template.New(path).Option("missingkey=error").Funcs(templateFuncs).Parse(string(raw))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse container templates from %s", path)
+	}
+	for _, t := range tmpl.Templates() {
+		if err := checkQuoted(t, t.Root, &taint{vars: map[string]bool{}}); err != nil {
+			return nil, errors.Wrapf(err, "unsafe container templates %s", path)
+		}
+	}
+	return &ContainerTemplates{tmpl: tmpl}, nil
+}
+
+// taint tracks the user controlled values in the scope of the template node: the dot and the variables.
+// The variables are shared by all scopes and never cleared, so an assignment in a nested scope can't hide a value.
+type taint struct {
+	dot  bool
+	vars map[string]bool
+}
+
+func (t *taint) with(dot bool) *taint {
+	return &taint{dot: dot, vars: t.vars}
+}
+
+func (t *taint) set(v *parse.VariableNode, tainted bool) {
+	t.vars[v.Ident[0]] = t.vars[v.Ident[0]] || tainted
+}
+
+// checkQuoted returns an error if the template outputs a value of userFields not passed through quote or toJson.
+func checkQuoted(tmpl *template.Template, node parse.Node, t *taint) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := checkQuoted(tmpl, child, t); err != nil {
+				return err
+			}
+		}
+	case *parse.ActionNode:
+		tainted := t.isTainted(n.Pipe) && !isQuoted(n.Pipe)
+		if len(n.Pipe.Decl) != 0 {
+			for _, v := range n.Pipe.Decl {
+				t.set(v, tainted)
+			}
+			return nil
+		}
+		if tainted {
+			location, _ := tmpl.ErrorContext(n)
+			return errors.Errorf("%s: %s outputs a user controlled value, it must be quoted with quote or toJson", location, n)
+		}
+	case *parse.IfNode:
+		return checkBranch(tmpl, &n.BranchNode, t.with(t.dot), t.with(t.dot))
+	case *parse.WithNode:
+		return checkBranch(tmpl, &n.BranchNode, t.with(t.isTainted(n.Pipe) && !isQuoted(n.Pipe)), t.with(t.dot))
+	case *parse.RangeNode:
+		inner := t.with(t.isTainted(n.Pipe))
+		for _, v := range n.Pipe.Decl {
+			inner.set(v, inner.dot)
+		}
+		return checkBranch(tmpl, &n.BranchNode, inner, t.with(t.dot))
+	case *parse.TemplateNode:
+		if n.Pipe != nil && t.isTainted(n.Pipe) {
+			location, _ := tmpl.ErrorContext(n)
+			return errors.Errorf("%s: %s passes a user controlled value to the template, it must be quoted with quote or toJson", location, n)
+		}
+	}
+	return nil
+}
+
+func checkBranch(tmpl *template.Template, n *parse.BranchNode, list, elseList *taint) error {
+	if err := checkQuoted(tmpl, n.List, list); err != nil {
+		return err
+	}
+	return checkQuoted(tmpl, n.ElseList, elseList)
+}
+
+// isTainted checks whether any argument of the pipeline is a user controlled value.
+func (t *taint) isTainted(pipe *parse.PipeNode) bool {
+	for _, cmd := range pipe.Cmds {
+		for _, arg := range cmd.Args {
+			if t.isTaintedArg(arg) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (t *taint) isTaintedArg(arg parse.Node) bool {
+	switch a := arg.(type) {
+	case *parse.DotNode:
+		return t.dot
+	case *parse.FieldNode:
+		return userFields[a.Ident[0]] || t.dot
+	case *parse.VariableNode:
+		if a.Ident[0] == "$" {
+			return len(a.Ident) > 1 && userFields[a.Ident[1]]
+		}
+		return t.vars[a.Ident[0]]
+	case *parse.ChainNode:
+		return t.isTaintedArg(a.Node)
+	case *parse.PipeNode:
+		return t.isTainted(a) && !isQuoted(a)
+	}
+	return false
+}
+
+// isQuoted checks whether the result of the pipeline is passed through quote or toJson.
+func isQuoted(pipe *parse.PipeNode) bool {
+	if len(pipe.Cmds) == 0 {
+		return false
+	}
+	ident, ok := pipe.Cmds[len(pipe.Cmds)-1].Args[0].(*parse.IdentifierNode)
+	return ok && (ident.Ident == "quote" || ident.Ident == "toJson")
+}
+
+// Render executes templates with passed data and decodes the result into a list of corev1.Container.
+func (t *ContainerTemplates) Render(data *TemplateData) ([]corev1.Container, error) {
+	if t == nil {
+		return nil, nil
+	}
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, data); err != nil {
+		return nil, errors.Wrapf(err, "failed to execute container templates %s", t.tmpl.Name())
+	}
+	var containers []corev1.Container
+	if err := yaml.NewYAMLOrJSONDecoder(&buf, buf.Len()+1).Decode(&containers); err != nil && err != io.EOF {
+		return nil, errors.Wrapf(err, "failed to decode container templates %s", t.tmpl.Name())
+	}
+	return containers, nil
+}
+
+// NameData is passed to NameTemplate on rendering.
+type NameData struct {
+	// Kind is the kind of the admitted resource.
+	Kind string
+	// Namespace is the namespace of the admitted resource.
+	Namespace string
+	// Name is the name of the admitted resource.
+	Name string
+	// UID is the UID of the admitted resource, usually empty on creation.
+	UID string
+	// Hash is the hash of Kind, Namespace, Name and UID used by HashNameStrategy.
+	Hash string
+}
+
+// NameTemplate is a parsed Go template of NSM_NAME env.
+type NameTemplate struct {
+	tmpl *template.Template
+}
+
+// ParseNameTemplate parses the template of NSM_NAME env. Returns nil if the template is empty.
+func ParseNameTemplate(text string) (*NameTemplate, error) {
+	if text == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("name").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse name template")
+	}
+	return &NameTemplate{tmpl: tmpl}, nil
+}
+
+// Render executes the template with passed data.
+func (t *NameTemplate) Render(data *NameData) (string, error) {
+	var sb strings.Builder
+	if err := t.tmpl.Execute(&sb, data); err != nil {
+		return "", errors.Wrap(err, "failed to execute name template")
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/config"
+)
+
+func TestContainerTemplates_Render_Quote(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "templates.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+- name: nsc
+  image: nsc
+  args: [{{ printf "--namespace=%s" .Namespace | quote }}]
+  env:
+    - name: ANNOTATION
+      value: {{ .Annotation | quote }}
+    - name: ENVS
+      value: {{ .Envs | toJson | quote }}
+`), 0o600))
+	templates, err := config.LoadContainerTemplates(path)
+	require.NoError(t, err)
+
+	for _, annotation := range []string{
+		`kernel://ns/nsm-1?app=a"b`,
+		`kernel://ns?a=b: c`,
+		"x\"\n  securityContext:\n    privileged: true\n#",
+	} {
+		containers, err := templates.Render(&config.TemplateData{
+			Namespace:  "ns",
+			Annotation: annotation,
+			Envs:       map[string]string{"NAME": `"value"`},
+		})
+		require.NoError(t, err, annotation)
+		require.Len(t, containers, 1)
+		require.Nil(t, containers[0].SecurityContext)
+		require.Equal(t, []string{"--namespace=ns"}, containers[0].Args)
+		require.Equal(t, annotation, containers[0].Env[0].Value)
+		require.Equal(t, `{"NAME":"\"value\""}`, containers[0].Env[1].Value)
+	}
+}
+
+func TestLoadContainerTemplates_Unquoted(t *testing.T) {
+	for _, tc := range []struct {
+		name, template string
+		valid          bool
+	}{
+		{name: "quoted", template: `value: {{ .Annotation | quote }}`, valid: true},
+		{name: "json", template: `value: {{ toJson .Envs }}`, valid: true},
+		{name: "quoted in printf", template: `value: {{ printf "-%s" (.PodName | quote) }}`, valid: true},
+		{name: "namespace", template: `value: {{ .Namespace }}`, valid: true},
+		{name: "condition", template: `{{ if .Annotation }}value: a{{ end }}`, valid: true},
+		{name: "quoted variable", template: `{{ $a := .Annotation | quote }}value: {{ $a }}`, valid: true},
+		{name: "annotation", template: `value: {{ .Annotation }}`},
+		{name: "env", template: `value: {{ .Envs.NSM_NAME }}`},
+		{name: "pod name", template: `value: {{ printf "%s" .PodName }}`},
+		{name: "root variable", template: `value: {{ $.Annotation }}`},
+		{name: "variable", template: `{{ $a := .Annotation }}value: {{ $a }}`},
+		{name: "assigned in nested scope", template: `{{ $a := "a" }}{{ if true }}{{ $a = .Annotation }}{{ end }}value: {{ $a }}`},
+		{name: "with", template: `{{ with .Annotation }}value: {{ . }}{{ end }}`},
+		{name: "range", template: `{{ range $k, $v := .Envs }}{{ $k }}: {{ $v }}{{ end }}`},
+		{name: "quoted twice then unquoted", template: `value: {{ .Annotation | quote | printf "%s" }}`},
+		{name: "template", template: `{{ define "v" }}{{ . }}{{ end }}value: {{ template "v" .Annotation }}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "templates.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tc.template), 0o600))
+			_, err := config.LoadContainerTemplates(path)
+			if tc.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
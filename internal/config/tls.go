@@ -0,0 +1,147 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// TLSVersion internal minimum TLS version type.
+type TLSVersion uint16
+
+// Decode takes a string TLS version, e.g. '1.2', and returns the TLSVersion.
+func (v *TLSVersion) Decode(version string) error {
+	switch strings.TrimPrefix(strings.ToUpper(version), "TLS") {
+	case "1.2", "12":
+		*v = tls.VersionTLS12
+		return nil
+	case "1.3", "13":
+		*v = tls.VersionTLS13
+		return nil
+	}
+	return errors.Errorf("not a valid TLS version: %s, supported versions are 1.2 and 1.3", version)
+}
+
+var curves = map[string]tls.CurveID{
+	"X25519": tls.X25519,
+	"P256":   tls.CurveP256,
+	"P384":   tls.CurveP384,
+	"P521":   tls.CurveP521,
+}
+
+// ParseCipherSuites converts IANA names of the cipher suites, e.g. TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, into their IDs.
+// Only the suites considered secure by crypto/tls are accepted.
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	var ids []uint16
+	for _, name := range names {
+		id, ok := cipherSuiteID(strings.TrimSpace(name))
+		if !ok {
+			return nil, errors.Errorf("not a valid or not a secure cipher suite: %s", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func cipherSuiteID(name string) (uint16, bool) {
+	for _, suite := range tls.CipherSuites() {
+		if suite.Name == name {
+			return suite.ID, true
+		}
+	}
+	return 0, false
+}
+
+// parseCipherSuites parses Config.TLSCipherSuites. They are rejected with the minimum version TLS 1.3, crypto/tls
+// doesn't configure TLS 1.3 suites, so they would be ignored silently.
+func (c *Config) parseCipherSuites() ([]uint16, error) {
+	if len(c.TLSCipherSuites) != 0 && c.TLSMinVersion == tls.VersionTLS13 {
+		return nil, errors.New("TLS cipher suites must not be specified for the minimum TLS version 1.3, TLS 1.3 suites aren't configurable")
+	}
+	return ParseCipherSuites(c.TLSCipherSuites)
+}
+
+// ParseCurves converts names of the curves into their IDs: X25519, P256, P384 and P521.
+func ParseCurves(names []string) ([]tls.CurveID, error) {
+	var ids []tls.CurveID
+	for _, name := range names {
+		id, ok := curves[strings.ToUpper(strings.TrimSpace(name))]
+		if !ok {
+			return nil, errors.Errorf("not a valid curve: %s, supported curves are X25519, P256, P384 and P521", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// LoadClientCAs reads the PEM encoded CA certificates verifying client certificates. Returns nil if path is empty.
+func LoadClientCAs(path string) (*x509.CertPool, error) {
+	if path == "" {
+		return nil, nil
+	}
+	pem, err := os.ReadFile(path) // #nosec
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read client CA bundle from %s", path)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.Errorf("no PEM encoded certificates in client CA bundle %s", path)
+	}
+	return pool, nil
+}
+
+// IsClientCertificateRequired checks whether /mutate requires client certificates verified by Config.ClientCAFilePath.
+func (c *Config) IsClientCertificateRequired() bool {
+	return c.ClientCAFilePath != ""
+}
+
+// NewTLSConfig creates the TLS config of the admission listener without the serving certificate.
+// If client certificates are required, they are verified in the handshake only if the operational endpoints are served
+// by the separate listener, otherwise the probes of kubelet without client certificates would fail.
+func (c *Config) NewTLSConfig() (*tls.Config, error) {
+	cipherSuites, err := c.parseCipherSuites()
+	if err != nil {
+		return nil, err
+	}
+	curvePreferences, err := ParseCurves(c.TLSCurvePreferences)
+	if err != nil {
+		return nil, err
+	}
+	clientCAs, err := LoadClientCAs(c.ClientCAFilePath)
+	if err != nil {
+		return nil, err
+	}
+	// #nosec G402 -- MinVersion is validated by TLSVersion.Decode
+	tlsConfig := &tls.Config{
+		MinVersion:       uint16(c.TLSMinVersion),
+		CipherSuites:     cipherSuites,
+		CurvePreferences: curvePreferences,
+	}
+	if clientCAs != nil {
+		tlsConfig.ClientCAs = clientCAs
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		if c.OperationalListenOn != "" {
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config_test
+
+import (
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/config"
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/config/configtest"
+)
+
+func TestConfig_NewTLSConfig(t *testing.T) {
+	clientCAFilePath := filepath.Join(t.TempDir(), "ca.crt")
+	caConf := configtest.New(t)
+	caConf.WebhookMode = config.SelfregisterMode
+	require.NoError(t, os.WriteFile(clientCAFilePath, caConf.GetOrResolveCABundle(), 0o600))
+	notPEMFilePath := filepath.Join(t.TempDir(), "ca.txt")
+	require.NoError(t, os.WriteFile(notPEMFilePath, []byte("not a certificate"), 0o600))
+
+	for name, test := range map[string]struct {
+		envs     map[string]string
+		expected *tls.Config
+		err      string
+	}{
+		"defaults": {
+			expected: &tls.Config{MinVersion: tls.VersionTLS12},
+		},
+		"hardened": {
+			envs: map[string]string{
+				"NSM_TLS_MIN_VERSION":       "TLS1.2",
+				"NSM_TLS_CIPHER_SUITES":     "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384, TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+				"NSM_TLS_CURVE_PREFERENCES": "p384,X25519",
+			},
+			expected: &tls.Config{
+				MinVersion:       tls.VersionTLS12,
+				CipherSuites:     []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384, tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384},
+				CurvePreferences: []tls.CurveID{tls.CurveP384, tls.X25519},
+			},
+		},
+		"TLS 1.3": {
+			envs:     map[string]string{"NSM_TLS_MIN_VERSION": "1.3"},
+			expected: &tls.Config{MinVersion: tls.VersionTLS13},
+		},
+		"cipher suites with TLS 1.3": {
+			envs: map[string]string{
+				"NSM_TLS_MIN_VERSION":   "1.3",
+				"NSM_TLS_CIPHER_SUITES": "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+			},
+			err: "TLS cipher suites must not be specified for the minimum TLS version 1.3",
+		},
+		"insecure cipher suite": {
+			envs: map[string]string{"NSM_TLS_CIPHER_SUITES": "TLS_RSA_WITH_RC4_128_SHA"},
+			err:  "not a valid or not a secure cipher suite: TLS_RSA_WITH_RC4_128_SHA",
+		},
+		"unknown curve": {
+			envs: map[string]string{"NSM_TLS_CURVE_PREFERENCES": "P224"},
+			err:  "not a valid curve: P224",
+		},
+		"client CA": {
+			envs:     map[string]string{"NSM_CLIENT_CA_FILE_PATH": clientCAFilePath},
+			expected: &tls.Config{MinVersion: tls.VersionTLS12, ClientAuth: tls.VerifyClientCertIfGiven},
+		},
+		"client CA with operational listener": {
+			envs: map[string]string{
+				"NSM_CLIENT_CA_FILE_PATH":   clientCAFilePath,
+				"NSM_OPERATIONAL_LISTEN_ON": ":8080",
+			},
+			expected: &tls.Config{MinVersion: tls.VersionTLS12, ClientAuth: tls.RequireAndVerifyClientCert},
+		},
+		"missing client CA": {
+			envs: map[string]string{"NSM_CLIENT_CA_FILE_PATH": "/nonexistent/ca.crt"},
+			err:  "failed to read client CA bundle from /nonexistent/ca.crt",
+		},
+		"not PEM client CA": {
+			envs: map[string]string{"NSM_CLIENT_CA_FILE_PATH": notPEMFilePath},
+			err:  "no PEM encoded certificates in client CA bundle",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			for key, value := range test.envs {
+				t.Setenv(key, value)
+			}
+			conf := configtest.New(t)
+			tlsConfig, err := conf.NewTLSConfig()
+			if test.err != "" {
+				require.ErrorContains(t, err, test.err)
+				require.ErrorContains(t, conf.Validate(), test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, conf.Validate())
+			require.Equal(t, test.expected.MinVersion, tlsConfig.MinVersion)
+			require.Equal(t, test.expected.CipherSuites, tlsConfig.CipherSuites)
+			require.Equal(t, test.expected.CurvePreferences, tlsConfig.CurvePreferences)
+			require.Equal(t, test.expected.ClientAuth, tlsConfig.ClientAuth)
+			require.Equal(t, test.expected.ClientAuth != tls.NoClientCert, tlsConfig.ClientCAs != nil)
+		})
+	}
+}
+
+func TestTLSVersion_Decode(t *testing.T) {
+	for version, expected := range map[string]uint16{
+		"1.2":    tls.VersionTLS12,
+		"12":     tls.VersionTLS12,
+		"tls1.3": tls.VersionTLS13,
+		"TLS13":  tls.VersionTLS13,
+		"1.1":    0,
+		"1.0":    0,
+		"":       0,
+	} {
+		var v config.TLSVersion
+		err := v.Decode(version)
+		if expected == 0 {
+			require.Error(t, err, version)
+			continue
+		}
+		require.NoError(t, err, version)
+		require.Equal(t, expected, uint16(v), version)
+	}
+}
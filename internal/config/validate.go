@@ -0,0 +1,227 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/podsecurity"
+)
+
+// imageReferenceRegexp is a simplified grammar of github.com/distribution/reference: [domain[:port]/]path[:tag][@digest]
+var imageReferenceRegexp = regexp.MustCompile(`^(?:[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?)*(?::[0-9]+)?/)?` +
+	`[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*` +
+	`(?::[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127})?(?:@[a-zA-Z][a-zA-Z0-9]*(?:[-_+.][a-zA-Z][a-zA-Z0-9]*)*:[0-9a-fA-F]{32,})?$`)
+
+// problems collects all problems found by Config.Validate.
+type problems []string
+
+func (p *problems) add(format string, args ...interface{}) {
+	*p = append(*p, fmt.Sprintf(format, args...))
+}
+
+func (p *problems) check(err error) {
+	if err != nil {
+		p.add("%s", err.Error())
+	}
+}
+
+// Validate checks the whole configuration: names, certificates, profiles with their quantities, images, labels, envs
+// and templates, resource rules, pod security settings, listeners and TLS settings. Returns an error listing all found problems.
+func (c *Config) Validate() error {
+	var p problems
+	c.validateNames(&p)
+	c.validateCertificates(&p)
+	c.validateProfiles(&p)
+	_, err := LoadResourceRules(c.ResourceRulesFilePath)
+	p.check(err)
+	for resourceName, templateName := range c.ResourceClaimTemplates {
+		for _, msg := range validation.IsDNS1123Subdomain(templateName) {
+			p.add("not a valid ResourceClaimTemplate %q of resource %s: %s", templateName, resourceName, msg)
+		}
+	}
+	c.validateNameStrategy(&p)
+	c.validateListeners(&p)
+	_, err = c.parseCipherSuites()
+	p.check(err)
+	_, err = ParseCurves(c.TLSCurvePreferences)
+	p.check(err)
+	_, err = LoadClientCAs(c.ClientCAFilePath)
+	p.check(err)
+	_, err = podsecurity.ParseDefaults(c.PodSecurityDefaults)
+	p.check(err)
+	p.check(podsecurity.ValidateModes(c.PodSecurityModes))
+	if c.WebhookReconcilePeriod < 0 {
+		p.add("webhook reconcile period must not be negative: %s", c.WebhookReconcilePeriod)
+	}
+	if c.ShutdownGracePeriod < 0 {
+		p.add("shutdown grace period must not be negative: %s", c.ShutdownGracePeriod)
+	}
+	if c.ShutdownTimeout <= 0 {
+		p.add("shutdown timeout must be positive: %s", c.ShutdownTimeout)
+	}
+	if c.HealthCheckTimeout <= 0 {
+		p.add("health check timeout must be positive: %s", c.HealthCheckTimeout)
+	}
+	if c.MaxRequestBodySize <= 0 {
+		p.add("maximum request body size must be positive: %d", c.MaxRequestBodySize)
+	}
+	if c.KubeletQPS <= 0 {
+		p.add("kubelet QPS must be positive: %d", c.KubeletQPS)
+	}
+	if len(p) == 0 {
+		return nil
+	}
+	return errors.Errorf("invalid configuration:\n  - %s", strings.Join(p, "\n  - "))
+}
+
+func (c *Config) validateNames(p *problems) {
+	for _, annotation := range []string{c.Annotation, c.ProfileAnnotation, c.StatusAnnotation} {
+		for _, msg := range validation.IsQualifiedName(annotation) {
+			p.add("not a valid annotation name %q: %s", annotation, msg)
+		}
+	}
+	for _, msg := range validation.IsEnvVarName(c.NSURLEnvName) {
+		p.add("not a valid NS URL env name %q: %s", c.NSURLEnvName, msg)
+	}
+}
+
+func (c *Config) validateNameStrategy(p *problems) {
+	tmpl, err := ParseNameTemplate(c.NameTemplate)
+	if err != nil {
+		p.check(err)
+		return
+	}
+	if c.NameStrategy == TemplateNameStrategy && tmpl == nil {
+		p.add("name template must be specified for the template name strategy")
+	}
+}
+
+func (c *Config) validateListeners(p *problems) {
+	if _, _, err := net.SplitHostPort(c.ListenOn); err != nil {
+		p.add("not a valid listen address %q: %s", c.ListenOn, err.Error())
+	}
+	if c.OperationalListenOn != "" {
+		if _, _, err := net.SplitHostPort(c.OperationalListenOn); err != nil {
+			p.add("not a valid operational listen address %q: %s", c.OperationalListenOn, err.Error())
+		}
+		if c.OperationalListenOn == c.ListenOn {
+			p.add("admission and operational listeners must have different addresses: %s", c.ListenOn)
+		}
+	}
+	for _, msg := range validation.IsValidPortNum(int(c.ServicePort)) {
+		p.add("not a valid service port %d: %s", c.ServicePort, msg)
+	}
+}
+
+func (c *Config) validateCertificates(p *problems) {
+	if (c.CertFilePath == "") != (c.KeyFilePath == "") {
+		p.add("certificate and key files must be specified together")
+		return
+	}
+	if !c.IsExistingCertificatesUsed() {
+		return
+	}
+	if _, err := tls.LoadX509KeyPair(c.CertFilePath, c.KeyFilePath); err != nil {
+		p.add("failed to load certificate %s: %s", c.CertFilePath, err.Error())
+	}
+	if c.WebhookMode != SelfregisterMode {
+		return
+	}
+	if c.CABundleFilePath == "" {
+		p.add("CA bundle file must be specified for the existing certificate in selfregister mode")
+		return
+	}
+	if _, err := os.ReadFile(c.CABundleFilePath); err != nil {
+		p.add("failed to read CA bundle: %s", err.Error())
+	}
+}
+
+func (c *Config) validateProfiles(p *problems) {
+	defaultProfile, err := c.newDefaultProfile()
+	if err != nil {
+		p.check(err)
+		return
+	}
+	err = defaultProfile.resolve(c, nil)
+	p.check(err)
+	// the default profile may be empty if the resources select named profiles
+	defaultProfile.validate(p, err == nil && c.ProfilesFilePath == "")
+
+	profiles, err := LoadProfiles(c.ProfilesFilePath)
+	p.check(err)
+	names := map[string]bool{DefaultProfileName: true}
+	for _, profile := range profiles {
+		if profile.Name == "" {
+			p.add("profile name is not specified in %s", c.ProfilesFilePath)
+			continue
+		}
+		if names[profile.Name] {
+			p.add("duplicated profile %s in %s", profile.Name, c.ProfilesFilePath)
+			continue
+		}
+		names[profile.Name] = true
+		err := profile.resolve(c, defaultProfile)
+		p.check(err)
+		profile.validate(p, err == nil)
+	}
+}
+
+// validate checks quantities, images and labels of the profile. Templates are checked only if required containers are.
+func (pr *Profile) validate(p *problems, requireContainers bool) {
+	if requireContainers && len(pr.InitContainerImages)+len(pr.ContainerImages) == 0 && pr.initContainerTemplates == nil && pr.containerTemplates == nil {
+		p.add("profile %s: no containers to inject, specify images or container templates", pr.Name)
+	}
+	for _, img := range append(append([]string(nil), pr.InitContainerImages...), pr.ContainerImages...) {
+		if !imageReferenceRegexp.MatchString(img) {
+			p.add("profile %s: not a valid image reference %q", pr.Name, img)
+		}
+	}
+	for key, value := range pr.Labels {
+		for _, msg := range validation.IsQualifiedName(key) {
+			p.add("profile %s: not a valid label key %q: %s", pr.Name, key, msg)
+		}
+		for _, msg := range validation.IsValidLabelValue(value) {
+			p.add("profile %s: not a valid value %q of label %s: %s", pr.Name, value, key, msg)
+		}
+	}
+	validateSidecarResources(p, pr.Name, "cpu", pr.SidecarRequestsCPU, pr.SidecarLimitsCPU)
+	validateSidecarResources(p, pr.Name, "memory", pr.SidecarRequestsMemory, pr.SidecarLimitsMemory)
+}
+
+func validateSidecarResources(p *problems, profileName, resourceName, requests, limits string) {
+	requestsQuantity, requestsErr := resource.ParseQuantity(requests)
+	if requestsErr != nil {
+		p.add("profile %s: invalid sidecar %s requests %q: %s", profileName, resourceName, requests, requestsErr.Error())
+	}
+	limitsQuantity, limitsErr := resource.ParseQuantity(limits)
+	if limitsErr != nil {
+		p.add("profile %s: invalid sidecar %s limits %q: %s", profileName, resourceName, limits, limitsErr.Error())
+	}
+	if requestsErr == nil && limitsErr == nil && requestsQuantity.Cmp(limitsQuantity) > 0 {
+		p.add("profile %s: sidecar %s requests %s exceed limits %s", profileName, resourceName, requests, limits)
+	}
+}
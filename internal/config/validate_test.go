@@ -0,0 +1,148 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/config"
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/config/configtest"
+)
+
+const digest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestConfig_Validate(t *testing.T) {
+	for name, test := range map[string]struct {
+		modify   func(c *config.Config)
+		problems []string
+	}{
+		"defaults": {
+			modify: func(*config.Config) {},
+		},
+		"annotation": {
+			modify:   func(c *config.Config) { c.Annotation = "networkservicemesh.io/ns url" },
+			problems: []string{`not a valid annotation name "networkservicemesh.io/ns url"`},
+		},
+		"NS URL env name": {
+			modify:   func(c *config.Config) { c.NSURLEnvName = "1NSM" },
+			problems: []string{`not a valid NS URL env name "1NSM"`},
+		},
+		"certificate without key": {
+			modify:   func(c *config.Config) { c.CertFilePath = "tls.crt" },
+			problems: []string{"certificate and key files must be specified together"},
+		},
+		"missing certificate": {
+			modify:   func(c *config.Config) { c.CertFilePath, c.KeyFilePath = "/nonexistent/tls.crt", "/nonexistent/tls.key" },
+			problems: []string{"failed to load certificate /nonexistent/tls.crt"},
+		},
+		"resource claim template": {
+			modify:   func(c *config.Config) { c.ResourceClaimTemplates = map[string]string{"sriov": "SR-IOV"} },
+			problems: []string{`not a valid ResourceClaimTemplate "SR-IOV" of resource sriov`},
+		},
+		"template name strategy without template": {
+			modify:   func(c *config.Config) { c.NameStrategy = config.TemplateNameStrategy },
+			problems: []string{"name template must be specified for the template name strategy"},
+		},
+		"listen address": {
+			modify:   func(c *config.Config) { c.ListenOn = "443" },
+			problems: []string{`not a valid listen address "443"`},
+		},
+		"same listen addresses": {
+			modify:   func(c *config.Config) { c.OperationalListenOn = c.ListenOn },
+			problems: []string{"admission and operational listeners must have different addresses: :443"},
+		},
+		"service port": {
+			modify:   func(c *config.Config) { c.ServicePort = 0 },
+			problems: []string{"not a valid service port 0"},
+		},
+		"sidecar resources": {
+			modify:   func(c *config.Config) { c.SidecarRequestsCPU, c.SidecarLimitsMemory = "1", "80 Mi" },
+			problems: []string{"sidecar cpu requests 1 exceed limits 200m", `invalid sidecar memory limits "80 Mi"`},
+		},
+		"durations and sizes": {
+			modify: func(c *config.Config) {
+				c.WebhookReconcilePeriod, c.ShutdownGracePeriod, c.ShutdownTimeout, c.HealthCheckTimeout = -time.Second, -time.Second, 0, 0
+				c.MaxRequestBodySize, c.KubeletQPS = 0, 0
+			},
+			problems: []string{
+				"webhook reconcile period must not be negative: -1s",
+				"shutdown grace period must not be negative: -1s",
+				"shutdown timeout must be positive: 0s",
+				"health check timeout must be positive: 0s",
+				"maximum request body size must be positive: 0",
+				"kubelet QPS must be positive: 0",
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			conf := configtest.New(t)
+			test.modify(conf)
+			err := conf.Validate()
+			if len(test.problems) == 0 {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			for _, problem := range test.problems {
+				require.Contains(t, err.Error(), problem)
+			}
+			// every problem is listed on its own line
+			require.Len(t, strings.Split(err.Error(), "\n  - "), len(test.problems)+1, err.Error())
+		})
+	}
+}
+
+func TestConfig_Validate_ImageReference(t *testing.T) {
+	for image, valid := range map[string]bool{
+		"nsc":                                   true,
+		"cmd-nsc:v1.14.0":                       true,
+		"networkservicemesh/cmd-nsc":            true,
+		"ghcr.io/networkservicemesh/cmd-nsc":    true,
+		"localhost:5000/nsm/cmd-nsc:latest":     true,
+		"registry.example.com/a/b/c_d__e.f-g":   true,
+		"cmd-nsc@" + digest:                     true,
+		"ghcr.io/nsm/cmd-nsc:v1.14.0@" + digest: true,
+		"":                                      false,
+		"Cmd-nsc":                               false,
+		"cmd-nsc:":                              false,
+		":latest":                               false,
+		"nsm//cmd-nsc":                          false,
+		"cmd-nsc-":                              false,
+		"-registry.io/cmd-nsc":                  false,
+		"cmd-nsc:v1 .0":                         false,
+		"cmd-nsc:.v1":                           false,
+		"cmd-nsc@sha256:0123":                   false,
+		"cmd-nsc@" + digest + "@" + digest:      false,
+		"https://ghcr.io/nsm/cmd-nsc":           false,
+	} {
+		t.Run(image, func(t *testing.T) {
+			conf := configtest.New(t)
+			conf.ContainerImages = []string{image}
+			err := conf.Validate()
+			if valid {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "not a valid image reference")
+		})
+	}
+}
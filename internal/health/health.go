@@ -0,0 +1,166 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package health provides liveness and readiness checks for cmd-admission-webhook-k8s
+package health
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// These are the statuses of checks and of the whole report.
+const (
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+)
+
+// Check returns nil if the checked dependency is healthy.
+type Check func(ctx context.Context) error
+
+// CheckResult is the result of a single check in the report.
+type CheckResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report is the JSON breakdown of all checks returned by Checker.Handler.
+type Report struct {
+	Status string                  `json:"status"`
+	Checks map[string]*CheckResult `json:"checks"`
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker runs named checks concurrently, each of them within the timeout.
+type Checker struct {
+	timeout time.Duration
+	mu      sync.RWMutex
+	checks  []namedCheck
+}
+
+// NewChecker creates Checker without checks.
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add adds the named check.
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Run runs all checks and returns the report.
+func (c *Checker) Run(ctx context.Context) *Report {
+	c.mu.RLock()
+	checks := append([]namedCheck(nil), c.checks...)
+	c.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	results := make([]*CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, nc := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = &CheckResult{Status: StatusOK}
+			if err := nc.check(ctx); err != nil {
+				results[i] = &CheckResult{Status: StatusFailed, Error: err.Error()}
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := &Report{Status: StatusOK, Checks: make(map[string]*CheckResult, len(checks))}
+	for i, nc := range checks {
+		report.Checks[nc.name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFailed
+		}
+	}
+	return report
+}
+
+// Handler returns the handler responding with the report: 200 if all checks pass, 503 otherwise.
+func (c *Checker) Handler() echo.HandlerFunc {
+	return func(ctx echo.Context) error {
+		report := c.Run(ctx.Request().Context())
+		code := http.StatusOK
+		if report.Status != StatusOK {
+			code = http.StatusServiceUnavailable
+		}
+		return ctx.JSON(code, report)
+	}
+}
+
+// CertificateCheck checks that the serving certificate of tlsConfig is valid now.
+// If required is false, the check passes while there is no certificate yet, e.g. SPIRE hasn't issued the SVID.
+func CertificateCheck(tlsConfig *tls.Config, required bool) Check {
+	return func(context.Context) error {
+		cert, err := certificateOf(tlsConfig)
+		if err != nil {
+			if required {
+				return err
+			}
+			return nil
+		}
+		now := time.Now()
+		if now.Before(cert.NotBefore) {
+			return errors.Errorf("certificate is not valid before %s", cert.NotBefore.Format(time.RFC3339))
+		}
+		if now.After(cert.NotAfter) {
+			return errors.Errorf("certificate expired at %s", cert.NotAfter.Format(time.RFC3339))
+		}
+		return nil
+	}
+}
+
+func certificateOf(tlsConfig *tls.Config) (*x509.Certificate, error) {
+	var cert *tls.Certificate
+	switch {
+	case tlsConfig.GetCertificate != nil:
+		var err error
+		if cert, err = tlsConfig.GetCertificate(&tls.ClientHelloInfo{}); err != nil {
+			return nil, errors.Wrap(err, "no certificate loaded")
+		}
+	case len(tlsConfig.Certificates) > 0:
+		cert = &tlsConfig.Certificates[0]
+	}
+	if cert == nil || len(cert.Certificate) == 0 {
+		return nil, errors.New("no certificate loaded")
+	}
+	if cert.Leaf != nil {
+		return cert.Leaf, nil
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse certificate")
+	}
+	return leaf, nil
+}
@@ -0,0 +1,194 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/health"
+)
+
+func pass(context.Context) error { return nil }
+
+func fail(context.Context) error { return errors.New("unavailable") }
+
+func block(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestChecker_Run(t *testing.T) {
+	for name, test := range map[string]struct {
+		checks   map[string]health.Check
+		status   string
+		statuses map[string]string
+	}{
+		"no checks": {
+			status:   health.StatusOK,
+			statuses: map[string]string{},
+		},
+		"all passed": {
+			checks:   map[string]health.Check{"a": pass, "b": pass},
+			status:   health.StatusOK,
+			statuses: map[string]string{"a": health.StatusOK, "b": health.StatusOK},
+		},
+		"one failed": {
+			checks:   map[string]health.Check{"a": pass, "b": fail},
+			status:   health.StatusFailed,
+			statuses: map[string]string{"a": health.StatusOK, "b": health.StatusFailed},
+		},
+		"timed out": {
+			checks:   map[string]health.Check{"a": pass, "b": block},
+			status:   health.StatusFailed,
+			statuses: map[string]string{"a": health.StatusOK, "b": health.StatusFailed},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			checker := health.NewChecker(time.Millisecond * 100)
+			for checkName, check := range test.checks {
+				checker.Add(checkName, check)
+			}
+			report := checker.Run(context.Background())
+			require.Equal(t, test.status, report.Status)
+			require.Len(t, report.Checks, len(test.statuses))
+			for checkName, status := range test.statuses {
+				require.Equal(t, status, report.Checks[checkName].Status, checkName)
+				require.Equal(t, status == health.StatusFailed, report.Checks[checkName].Error != "", checkName)
+			}
+		})
+	}
+}
+
+func TestChecker_Handler(t *testing.T) {
+	for name, test := range map[string]struct {
+		check health.Check
+		code  int
+		body  string
+	}{
+		"passed": {
+			check: pass,
+			code:  http.StatusOK,
+			body:  `{"status":"ok","checks":{"dependency":{"status":"ok"}}}`,
+		},
+		"failed": {
+			check: fail,
+			code:  http.StatusServiceUnavailable,
+			body:  `{"status":"failed","checks":{"dependency":{"status":"failed","error":"unavailable"}}}`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			checker := health.NewChecker(time.Second)
+			checker.Add("dependency", test.check)
+			rec := httptest.NewRecorder()
+			require.NoError(t, checker.Handler()(echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/readyz", http.NoBody), rec)))
+			require.Equal(t, test.code, rec.Code)
+			require.JSONEq(t, test.body, rec.Body.String())
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), new(health.Report)))
+		})
+	}
+}
+
+func newCertificate(t *testing.T, notBefore, notAfter time.Time) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "admission-webhook-k8s"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestCertificateCheck(t *testing.T) {
+	now := time.Now()
+	valid := newCertificate(t, now.Add(-time.Hour), now.Add(time.Hour))
+	for name, test := range map[string]struct {
+		certificates   []tls.Certificate
+		getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)
+		required       bool
+		err            string
+	}{
+		"valid": {
+			certificates: []tls.Certificate{valid},
+			required:     true,
+		},
+		"valid from GetCertificate": {
+			getCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) { return &valid, nil },
+			required:       true,
+		},
+		"expired": {
+			certificates: []tls.Certificate{newCertificate(t, now.Add(-time.Hour*2), now.Add(-time.Hour))},
+			err:          "certificate expired at",
+		},
+		"not yet valid": {
+			certificates: []tls.Certificate{newCertificate(t, now.Add(time.Hour), now.Add(time.Hour*2))},
+			err:          "certificate is not valid before",
+		},
+		"not parsable": {
+			certificates: []tls.Certificate{{Certificate: [][]byte{[]byte("not a certificate")}}},
+			required:     true,
+			err:          "failed to parse certificate",
+		},
+		"missing": {
+			required: true,
+			err:      "no certificate loaded",
+		},
+		"missing not required": {},
+		"not issued yet": {
+			getCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) { return nil, errors.New("no SVID") },
+			required:       true,
+			err:            "no certificate loaded: no SVID",
+		},
+		"not issued yet not required": {
+			getCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) { return nil, errors.New("no SVID") },
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tlsConfig := &tls.Config{
+				MinVersion:     tls.VersionTLS12,
+				Certificates:   test.certificates,
+				GetCertificate: test.getCertificate,
+			}
+			err := health.CertificateCheck(tlsConfig, test.required)(context.Background())
+			if test.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, test.err)
+		})
+	}
+}
@@ -2,51 +2,85 @@
 package imports
 
 import (
+	_ "bytes"
 	_ "context"
 	_ "crypto/rand"
 	_ "crypto/rsa"
+	_ "crypto/sha256"
 	_ "crypto/tls"
 	_ "crypto/x509"
 	_ "crypto/x509/pkix"
+	_ "encoding/hex"
 	_ "encoding/json"
 	_ "encoding/pem"
+	_ "flag"
 	_ "fmt"
 	_ "github.com/google/uuid"
 	_ "github.com/kelseyhightower/envconfig"
 	_ "github.com/labstack/echo/v4"
 	_ "github.com/labstack/echo/v4/middleware"
-	_ "github.com/networkservicemesh/sdk-k8s/pkg/tools/k8s"
 	_ "github.com/networkservicemesh/sdk/pkg/tools/nsurl"
 	_ "github.com/networkservicemesh/sdk/pkg/tools/opentelemetry"
 	_ "github.com/networkservicemesh/sdk/pkg/tools/pprofutils"
 	_ "github.com/pkg/errors"
 	_ "github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
 	_ "github.com/spiffe/go-spiffe/v2/workloadapi"
+	_ "github.com/stretchr/testify/require"
+	_ "go.opentelemetry.io/otel"
+	_ "go.opentelemetry.io/otel/attribute"
+	_ "go.opentelemetry.io/otel/metric"
 	_ "go.uber.org/zap"
 	_ "gomodules.xyz/jsonpatch/v2"
 	_ "io"
 	_ "k8s.io/api/admission/v1"
+	_ "k8s.io/api/admission/v1beta1"
 	_ "k8s.io/api/admissionregistration/v1"
 	_ "k8s.io/api/apps/v1"
 	_ "k8s.io/api/core/v1"
+	_ "k8s.io/apimachinery/pkg/api/equality"
 	_ "k8s.io/apimachinery/pkg/api/errors"
 	_ "k8s.io/apimachinery/pkg/api/resource"
 	_ "k8s.io/apimachinery/pkg/apis/meta/v1"
+	_ "k8s.io/apimachinery/pkg/fields"
 	_ "k8s.io/apimachinery/pkg/runtime"
+	_ "k8s.io/apimachinery/pkg/runtime/schema"
 	_ "k8s.io/apimachinery/pkg/runtime/serializer"
+	_ "k8s.io/apimachinery/pkg/util/validation"
+	_ "k8s.io/apimachinery/pkg/util/version"
+	_ "k8s.io/apimachinery/pkg/util/yaml"
+	_ "k8s.io/client-go/discovery"
+	_ "k8s.io/client-go/informers"
 	_ "k8s.io/client-go/kubernetes"
+	_ "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/kubernetes/typed/admissionregistration/v1"
+	_ "k8s.io/client-go/kubernetes/typed/core/v1"
 	_ "k8s.io/client-go/rest"
+	_ "k8s.io/client-go/tools/cache"
+	_ "k8s.io/client-go/tools/clientcmd"
+	_ "k8s.io/client-go/tools/record"
+	_ "k8s.io/client-go/tools/reference"
+	_ "k8s.io/client-go/util/retry"
 	_ "k8s.io/pod-security-admission/api"
+	_ "k8s.io/pod-security-admission/policy"
+	_ "maps"
 	_ "math/big"
+	_ "mime"
+	_ "net"
 	_ "net/http"
 	_ "net/url"
 	_ "os"
 	_ "os/signal"
 	_ "path"
+	_ "regexp"
+	_ "slices"
+	_ "sort"
 	_ "strconv"
 	_ "strings"
 	_ "sync"
+	_ "sync/atomic"
 	_ "syscall"
+	_ "testing"
+	_ "text/template"
+	_ "text/template/parse"
 	_ "time"
 )
@@ -0,0 +1,55 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/config"
+)
+
+// NewRESTConfig creates the config of all API server clients of the webhook. The kubeconfig is loaded from
+// Config.Kubeconfig, KUBECONFIG env or ~/.kube/config with Config.KubeContext selected, the in-cluster config is used
+// if there is no kubeconfig. QPS and burst are derived from Config.KubeletQPS.
+func NewRESTConfig(c *config.Config) (*rest.Config, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = c.Kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: c.KubeContext}
+	restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides).ClientConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build API server client config")
+	}
+	restConfig.QPS = float32(c.KubeletQPS)
+	restConfig.Burst = c.KubeletQPS * 2
+	return restConfig, nil
+}
+
+// NewClientset creates the clientset with the config returned by NewRESTConfig.
+func NewClientset(c *config.Config) (*kubernetes.Clientset, error) {
+	restConfig, err := NewRESTConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create API server client")
+	}
+	return clientset, nil
+}
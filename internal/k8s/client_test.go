@@ -0,0 +1,130 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/config"
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/config/configtest"
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/k8s"
+)
+
+const kubeconfigFormat = `apiVersion: v1
+kind: Config
+clusters:
+- name: first
+  cluster:
+    server: https://first.example.com
+- name: second
+  cluster:
+    server: https://second.example.com
+users:
+- name: admin
+  user:
+    token: token
+contexts:
+- name: first
+  context:
+    cluster: first
+    user: admin
+- name: second
+  context:
+    cluster: second
+    user: admin
+current-context: %s
+`
+
+// writeKubeconfig writes a kubeconfig of the clusters "first" and "second" with the given current context.
+func writeKubeconfig(t *testing.T, currentContext string) string {
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(kubeconfigFormat, currentContext)), 0o600))
+	return path
+}
+
+func TestNewRESTConfig(t *testing.T) {
+	for name, sample := range map[string]struct {
+		kubeconfig   string
+		envContext   string
+		kubeContext  string
+		expectedHost string
+	}{
+		"current context": {
+			kubeconfig:   "first",
+			expectedHost: "https://first.example.com",
+		},
+		"selected context": {
+			kubeconfig:   "first",
+			kubeContext:  "second",
+			expectedHost: "https://second.example.com",
+		},
+		"KUBECONFIG env": {
+			envContext:   "second",
+			expectedHost: "https://second.example.com",
+		},
+		"kubeconfig overrides KUBECONFIG env": {
+			kubeconfig:   "first",
+			envContext:   "second",
+			expectedHost: "https://first.example.com",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("KUBECONFIG", "")
+			if sample.envContext != "" {
+				t.Setenv("KUBECONFIG", writeKubeconfig(t, sample.envContext))
+			}
+			conf := configtest.New(t)
+			if sample.kubeconfig != "" {
+				conf.Kubeconfig = writeKubeconfig(t, sample.kubeconfig)
+			}
+			conf.KubeContext = sample.kubeContext
+			conf.KubeletQPS = 10
+
+			restConfig, err := k8s.NewRESTConfig(conf)
+			require.NoError(t, err)
+			require.Equal(t, sample.expectedHost, restConfig.Host)
+			require.Equal(t, float32(10), restConfig.QPS)
+			require.Equal(t, 20, restConfig.Burst)
+		})
+	}
+}
+
+func TestNewRESTConfig_Invalid(t *testing.T) {
+	for name, modify := range map[string]func(t *testing.T, c *config.Config){
+		"unknown context": func(t *testing.T, c *config.Config) {
+			c.Kubeconfig = writeKubeconfig(t, "first")
+			c.KubeContext = "unknown"
+		},
+		"missing kubeconfig": func(t *testing.T, c *config.Config) {
+			c.Kubeconfig = filepath.Join(t.TempDir(), "missing")
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			conf := configtest.New(t)
+			modify(t, conf)
+			_, err := k8s.NewRESTConfig(conf)
+			require.ErrorContains(t, err, "failed to build API server client config")
+			_, err = k8s.NewClientset(conf)
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/tools/reference"
+
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/config"
+)
+
+// These are the reasons of the events emitted on corrections of the webhook configuration.
+const (
+	WebhookConfigurationRestoredReason = "WebhookConfigurationRestored"
+	WebhookConfigurationRevertedReason = "WebhookConfigurationReverted"
+)
+
+const correctionsMetricName = "admission_webhook_configuration_corrections"
+
+// WebhookConfigurationReconciler watches the MutatingWebhookConfiguration named Config.Name registered in selfregister
+// mode and re-applies the desired spec if the configuration is deleted or modified, e.g. by an admin or a Helm upgrade.
+// Each correction is reported by an event of the configuration in Config.Namespace and by the corrections metric.
+// Without the shared certificate, see config.Config.IsExistingCertificatesUsed, only the configuration owned by the
+// replica is corrected.
+type WebhookConfigurationReconciler struct {
+	logger      *zap.SugaredLogger
+	config      *config.Config
+	clientset   kubernetes.Interface
+	broadcaster record.EventBroadcaster
+	recorder    record.EventRecorder
+	corrections metric.Int64Counter
+	owner       string
+	owned       bool // the last observed ownership, the reconciler is started after the registration by the owner
+	trigger     chan struct{}
+	hasSynced   cache.InformerSynced
+	cancel      context.CancelFunc
+	done        chan struct{}
+}
+
+// NewWebhookConfigurationReconciler creates WebhookConfigurationReconciler using the passed clientset, see NewClientset.
+// The restored configuration is marked by OwnerAnnotation with the owner, see AdmissionWebhookRegisterClient.Owner.
+func NewWebhookConfigurationReconciler(logger *zap.SugaredLogger, c *config.Config, clientset kubernetes.Interface, owner string) (*WebhookConfigurationReconciler, error) {
+	corrections, err := otel.Meter("").Int64Counter(correctionsMetricName,
+		metric.WithDescription("Number of corrections of the deleted or modified MutatingWebhookConfiguration"))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create %s metric", correctionsMetricName)
+	}
+	broadcaster := record.NewBroadcaster()
+	return &WebhookConfigurationReconciler{
+		logger:      logger,
+		config:      c,
+		clientset:   clientset,
+		broadcaster: broadcaster,
+		recorder:    broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: c.Name}),
+		corrections: corrections,
+		owner:       owner,
+		owned:       true,
+		trigger:     make(chan struct{}, 1),
+	}, nil
+}
+
+// Start starts watching the configuration, it's also checked every Config.WebhookReconcilePeriod to retry failures.
+func (r *WebhookConfigurationReconciler) Start(ctx context.Context) {
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.done = make(chan struct{})
+	r.broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: r.clientset.CoreV1().Events(r.config.Namespace)})
+
+	factory := informers.NewSharedInformerFactoryWithOptions(r.clientset, 0,
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", r.config.Name).String()
+		}))
+	informer := factory.Admissionregistration().V1().MutatingWebhookConfigurations().Informer()
+	r.hasSynced = informer.HasSynced
+	_, _ = informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { r.schedule() },
+		UpdateFunc: func(interface{}, interface{}) { r.schedule() },
+		DeleteFunc: func(interface{}) { r.schedule() },
+	})
+	factory.Start(ctx.Done())
+
+	go func() {
+		defer close(r.done)
+		defer factory.Shutdown()
+		ticker := time.NewTicker(r.config.WebhookReconcilePeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-r.trigger:
+			case <-ticker.C:
+			}
+			if err := r.reconcile(ctx); err != nil && ctx.Err() == nil {
+				r.logger.Errorf("failed to reconcile MutatingWebhookConfiguration %s: %v", r.config.Name, err.Error())
+			}
+		}
+	}()
+}
+
+// CheckSynced returns an error until the informer cache of the watched configuration is synced, the drift isn't
+// noticed before. It's a readiness check.
+func (r *WebhookConfigurationReconciler) CheckSynced(context.Context) error {
+	if r.hasSynced == nil || !r.hasSynced() {
+		return errors.Errorf("cache of MutatingWebhookConfiguration %s is not synced", r.config.Name)
+	}
+	return nil
+}
+
+// Stop stops the reconciliation and waits for the running one, so the configuration can be deregistered.
+func (r *WebhookConfigurationReconciler) Stop() {
+	r.cancel()
+	<-r.done
+	r.broadcaster.Shutdown()
+}
+
+func (r *WebhookConfigurationReconciler) schedule() {
+	select {
+	case r.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (r *WebhookConfigurationReconciler) reconcile(ctx context.Context) error {
+	client := r.clientset.AdmissionregistrationV1().MutatingWebhookConfigurations()
+	desired := newWebhookConfiguration(r.config, r.owner)
+	existing, err := client.Get(ctx, r.config.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if !r.isResponsible() {
+			return nil
+		}
+		if existing, err = client.Create(ctx, desired, metav1.CreateOptions{}); err != nil {
+			return errors.Wrap(err, "failed to restore deleted configuration")
+		}
+		r.corrected(ctx, existing, "deleted", WebhookConfigurationRestoredReason, "Restored the deleted configuration")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if r.owned = existing.Annotations[OwnerAnnotation] == r.owner; !r.isResponsible() {
+		return nil
+	}
+	if equality.Semantic.DeepEqual(existing.Webhooks, desired.Webhooks) {
+		return nil
+	}
+	existing.Webhooks = desired.Webhooks
+	updated, err := client.Update(ctx, existing, metav1.UpdateOptions{})
+	if err != nil {
+		return errors.Wrap(err, "failed to revert modified configuration")
+	}
+	r.corrected(ctx, updated, "modified", WebhookConfigurationRevertedReason, "Reverted the modified configuration to the desired spec")
+	return nil
+}
+
+// isResponsible checks whether the configuration is corrected by the reconciler. The replicas with the self signed in
+// memory certificates have different CA bundles, only the last observed owner of the configuration corrects it then.
+func (r *WebhookConfigurationReconciler) isResponsible() bool {
+	return r.owned || r.config.IsExistingCertificatesUsed()
+}
+
+func (r *WebhookConfigurationReconciler) corrected(ctx context.Context, object *admissionv1.MutatingWebhookConfiguration, drift, reason, message string) {
+	r.logger.Warnf("MutatingWebhookConfiguration %s was %s: %s", r.config.Name, drift, message)
+	if ref, err := reference.GetReference(scheme.Scheme, object); err != nil {
+		r.logger.Errorf("failed to record event of MutatingWebhookConfiguration %s: %v", r.config.Name, err.Error())
+	} else {
+		// the events of cluster-scoped objects are recorded in the default namespace otherwise
+		ref.Namespace = r.config.Namespace
+		r.recorder.Event(ref, corev1.EventTypeWarning, reason, message)
+	}
+	r.corrections.Add(ctx, 1, metric.WithAttributes(attribute.String("drift", drift)))
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/config"
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/config/configtest"
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/k8s"
+)
+
+func TestConfig_IsWebhookReconciliationEnabled(t *testing.T) {
+	conf := configtest.New(t)
+	conf.WebhookMode = config.SelfregisterMode
+	require.True(t, conf.IsWebhookReconciliationEnabled())
+
+	conf.CertFilePath, conf.KeyFilePath = "tls.crt", "tls.key"
+	require.True(t, conf.IsWebhookReconciliationEnabled())
+
+	conf.WebhookReconcilePeriod = 0
+	require.False(t, conf.IsWebhookReconciliationEnabled())
+
+	conf.WebhookReconcilePeriod, conf.WebhookMode = time.Minute, config.SpireMode
+	require.False(t, conf.IsWebhookReconciliationEnabled())
+}
+
+func TestWebhookConfigurationReconciler(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	conf := configtest.New(t)
+	conf.Namespace = "nsm-system"
+	clientset := fake.NewSimpleClientset()
+	registerClient := k8s.NewAdmissionWebhookRegisterClient(zap.NewNop().Sugar(), clientset)
+	require.NoError(t, registerClient.Register(ctx, conf))
+
+	reconciler, err := k8s.NewWebhookConfigurationReconciler(zap.NewNop().Sugar(), conf, clientset, registerClient.Owner())
+	require.NoError(t, err)
+	require.Error(t, reconciler.CheckSynced(ctx))
+	reconciler.Start(ctx)
+	defer reconciler.Stop()
+	require.Eventually(t, func() bool {
+		return reconciler.CheckSynced(ctx) == nil
+	}, time.Second*5, time.Millisecond*10)
+
+	client := clientset.AdmissionregistrationV1().MutatingWebhookConfigurations()
+	existing, err := client.Get(ctx, conf.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	existing.Webhooks[0].ClientConfig.CABundle = []byte("modified")
+	_, err = client.Update(ctx, existing, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		existing, err = client.Get(ctx, conf.Name, metav1.GetOptions{})
+		return err == nil && string(existing.Webhooks[0].ClientConfig.CABundle) == string(conf.GetOrResolveCABundle())
+	}, time.Second*5, time.Millisecond*10)
+	require.Equal(t, registerClient.Owner(), existing.Annotations[k8s.OwnerAnnotation])
+
+	require.Eventually(t, func() bool {
+		events, err := clientset.CoreV1().Events(conf.Namespace).List(ctx, metav1.ListOptions{})
+		return err == nil && len(events.Items) == 1 && events.Items[0].Reason == k8s.WebhookConfigurationRevertedReason
+	}, time.Second*5, time.Millisecond*10)
+}
+
+func TestWebhookConfigurationReconciler_NotOwned(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	conf := configtest.New(t)
+	clientset := fake.NewSimpleClientset()
+	first := k8s.NewAdmissionWebhookRegisterClient(zap.NewNop().Sugar(), clientset)
+	require.NoError(t, first.Register(ctx, conf))
+
+	reconciler, err := k8s.NewWebhookConfigurationReconciler(zap.NewNop().Sugar(), conf, clientset, first.Owner())
+	require.NoError(t, err)
+	reconciler.Start(ctx)
+	defer reconciler.Stop()
+
+	// another replica with its own in memory certificate takes the configuration over
+	client := clientset.AdmissionregistrationV1().MutatingWebhookConfigurations()
+	second := k8s.NewAdmissionWebhookRegisterClient(zap.NewNop().Sugar(), clientset)
+	require.NoError(t, second.Register(ctx, conf))
+	existing, err := client.Get(ctx, conf.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	existing.Webhooks[0].ClientConfig.CABundle = []byte("another replica")
+	_, err = client.Update(ctx, existing, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	require.Never(t, func() bool {
+		existing, err = client.Get(ctx, conf.Name, metav1.GetOptions{})
+		return err != nil || string(existing.Webhooks[0].ClientConfig.CABundle) != "another replica"
+	}, time.Millisecond*500, time.Millisecond*10)
+
+	// the configuration of the last observed owner isn't restored
+	require.NoError(t, client.Delete(ctx, conf.Name, metav1.DeleteOptions{}))
+	require.Never(t, func() bool {
+		_, err = client.Get(ctx, conf.Name, metav1.GetOptions{})
+		return err == nil
+	}, time.Millisecond*500, time.Millisecond*10)
+}
@@ -2,6 +2,8 @@
 //
 // Copyright (c) 2022 Cisco and/or its affiliates.
 //
+// Copyright (c) 2026 Nordix Foundation.
+//
 // SPDX-License-Identifier: Apache-2.0
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
@@ -20,71 +22,104 @@
 package k8s
 
 import (
+	"bytes"
 	"context"
 	"fmt"
-	"sync"
+	"os"
 
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	admissionv1 "k8s.io/api/admissionregistration/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	admissionregistrationv1 "k8s.io/client-go/kubernetes/typed/admissionregistration/v1"
-	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
 
 	"github.com/networkservicemesh/cmd-admission-webhook/internal/config"
 )
 
+// OwnerAnnotation is the annotation of the registered MutatingWebhookConfiguration naming the replica that registered it.
+const OwnerAnnotation = "networkservicemesh.io/registered-by"
+
 // AdmissionWebhookRegisterClient is a simple client that can register and unregister MutatingWebhookConfiguration based on config.Config
 type AdmissionWebhookRegisterClient struct {
 	Logger *zap.SugaredLogger
-	once   sync.Once
 	client admissionregistrationv1.AdmissionregistrationV1Interface
+	owner  string
 }
 
-func (a *AdmissionWebhookRegisterClient) initializeClient() {
-	c, err := rest.InClusterConfig()
-	if err != nil {
-		panic(err.Error())
-	}
-	clientset, err := kubernetes.NewForConfig(c)
-	if err != nil {
-		panic(err.Error())
+// NewAdmissionWebhookRegisterClient creates AdmissionWebhookRegisterClient using the passed clientset, see NewClientset.
+// The configurations registered by the client are marked by OwnerAnnotation with the unique name of the process.
+func NewAdmissionWebhookRegisterClient(logger *zap.SugaredLogger, clientset kubernetes.Interface) *AdmissionWebhookRegisterClient {
+	// the hostname is the pod name unless the pod uses the host network, the UUID distinguishes the processes anyway
+	hostname, _ := os.Hostname()
+	return &AdmissionWebhookRegisterClient{
+		Logger: logger,
+		client: clientset.AdmissionregistrationV1(),
+		owner:  fmt.Sprintf("%s/%s", hostname, uuid.NewString()),
 	}
-	a.client = clientset.AdmissionregistrationV1()
 }
 
-// Register registers MutatingWebhookConfiguration based on passed config.Config
+// Owner returns the value of OwnerAnnotation of the configurations registered by the client.
+func (a *AdmissionWebhookRegisterClient) Owner() string {
+	return a.owner
+}
+
+// Register registers MutatingWebhookConfiguration based on passed config.Config. The existing configuration is updated
+// to the desired spec instead of being re-created, so the configuration restored by the reconciler of another replica
+// in the meantime doesn't fail the registration.
 func (a *AdmissionWebhookRegisterClient) Register(ctx context.Context, c *config.Config) error {
-	a.once.Do(a.initializeClient)
 	a.Logger.Infof("Starting to register MutatingWebhookConfiguration based config: %#v", c)
 	defer a.Logger.Infof("Register for config %#v is done", c)
 
-	// When node is restarted, all apps started in pods with old names.
-	// Then we already have configuration with the same name but it can't be reused
-	// because it contains different certs (certs are regenerated on every program restart)
-	_, errExisting := a.client.MutatingWebhookConfigurations().Get(ctx, c.Name, metav1.GetOptions{})
-	if errExisting == nil {
-		a.Logger.Infof("Found existing MutatingWebhookConfiguration %s, unregistering", c.Name)
-		err := a.Unregister(ctx, c)
+	client := a.client.MutatingWebhookConfigurations()
+	desired := newWebhookConfiguration(c, a.owner)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		// When node is restarted, all apps started in pods with old names.
+		// Then we already have configuration with the same name but it can't be reused as is
+		// because it contains different certs (certs are regenerated on every program restart)
+		existing, err := client.Get(ctx, c.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			_, err = client.Create(ctx, desired, metav1.CreateOptions{})
+			if !apierrors.IsAlreadyExists(err) {
+				return errors.Wrapf(err, "failed to create MutatingWebhookConfiguration %s", c.Name)
+			}
+			existing, err = client.Get(ctx, c.Name, metav1.GetOptions{})
+		}
 		if err != nil {
-			return err
+			return errors.Wrapf(err, "failed to get MutatingWebhookConfiguration %s", c.Name)
 		}
-	} else if !apierrors.IsNotFound(errExisting) {
-		a.Logger.Errorf("Failed to search for existing MutatingWebhookConfiguration %s", c.Name)
-		return errExisting
-	}
+		a.Logger.Infof("Found existing MutatingWebhookConfiguration %s, updating", c.Name)
+		existing.Webhooks = desired.Webhooks
+		if existing.Annotations == nil {
+			existing.Annotations = map[string]string{}
+		}
+		existing.Annotations[OwnerAnnotation] = a.owner
+		_, err = client.Update(ctx, existing, metav1.UpdateOptions{})
+		return err
+	})
+}
 
+// newWebhookConfiguration returns the desired MutatingWebhookConfiguration. The fields defaulted by the API server
+// are set explicitly, so the registered configuration can be compared with it to find drift.
+func newWebhookConfiguration(c *config.Config, owner string) *admissionv1.MutatingWebhookConfiguration {
 	path := "/mutate"
+	port := c.ServicePort
 	policy := admissionv1.Fail
 	sideEffects := admissionv1.SideEffectClassNone
-	webhookConfig := &admissionv1.MutatingWebhookConfiguration{
+	matchPolicy := admissionv1.Equivalent
+	reinvocationPolicy := admissionv1.NeverReinvocationPolicy
+	scope := admissionv1.AllScopes
+	timeoutSeconds := int32(10)
+	return &admissionv1.MutatingWebhookConfiguration{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: c.Name,
+			Name:        c.Name,
+			Annotations: map[string]string{OwnerAnnotation: owner},
 		},
 		Webhooks: []admissionv1.MutatingWebhook{
 			{
-
 				Name: fmt.Sprintf("%v.%v", c.Name, c.Annotation),
 				Rules: []admissionv1.RuleWithOperations{
 					{
@@ -93,6 +128,7 @@ func (a *AdmissionWebhookRegisterClient) Register(ctx context.Context, c *config
 							APIGroups:   []string{""},
 							APIVersions: []string{"v1"},
 							Resources:   []string{"pods"},
+							Scope:       &scope,
 						},
 					},
 					{
@@ -101,32 +137,93 @@ func (a *AdmissionWebhookRegisterClient) Register(ctx context.Context, c *config
 							APIGroups:   []string{"apps"},
 							APIVersions: []string{"v1"},
 							Resources:   []string{"deployments", "statefulsets", "daemonsets", "replicasets"},
+							Scope:       &scope,
 						},
 					},
 				},
 				SideEffects:             &sideEffects,
-				AdmissionReviewVersions: []string{"v1"},
+				AdmissionReviewVersions: []string{"v1", "v1beta1"},
 				FailurePolicy:           &policy,
+				MatchPolicy:             &matchPolicy,
+				NamespaceSelector:       &metav1.LabelSelector{},
+				ObjectSelector:          &metav1.LabelSelector{},
+				TimeoutSeconds:          &timeoutSeconds,
+				ReinvocationPolicy:      &reinvocationPolicy,
 				ClientConfig: admissionv1.WebhookClientConfig{
 					Service: &admissionv1.ServiceReference{
 						Namespace: c.Namespace,
 						Name:      c.ServiceName,
 						Path:      &path,
+						Port:      &port,
 					},
 					CABundle: c.GetOrResolveCABundle(),
 				},
 			},
 		},
 	}
+}
+
+// CheckRegistered returns an error if MutatingWebhookConfiguration isn't registered or its webhooks don't trust
+// the certificate of this replica, e.g. another replica has registered it with its own CA bundle.
+func (a *AdmissionWebhookRegisterClient) CheckRegistered(ctx context.Context, c *config.Config) error {
+	existing, err := a.client.MutatingWebhookConfigurations().Get(ctx, c.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) || err == nil && len(existing.Webhooks) == 0 {
+		return errors.Errorf("MutatingWebhookConfiguration %s is not registered", c.Name)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to get MutatingWebhookConfiguration %s", c.Name)
+	}
+	for i := range existing.Webhooks {
+		if !bytes.Equal(existing.Webhooks[i].ClientConfig.CABundle, c.GetOrResolveCABundle()) {
+			return errors.Errorf("MutatingWebhookConfiguration %s doesn't trust the certificate of this replica", c.Name)
+		}
+	}
+	return nil
+}
+
+// IsOwned checks whether the registered MutatingWebhookConfiguration is still marked by OwnerAnnotation of this client.
+// Another replica may have registered the configuration again, it must not be deleted then.
+func (a *AdmissionWebhookRegisterClient) IsOwned(ctx context.Context, c *config.Config) (bool, error) {
+	owned, err := a.owned(ctx, c)
+	return owned != nil, err
+}
 
-	_, err := a.client.MutatingWebhookConfigurations().Create(ctx, webhookConfig, metav1.CreateOptions{})
-	return err
+// UnregisterOwned unregisters MutatingWebhookConfiguration if it's owned by this client, see IsOwned. The checked
+// configuration is deleted with the preconditions of its UID and resource version, so the configuration registered
+// by another replica in the meantime is kept. Returns false if the configuration isn't owned.
+func (a *AdmissionWebhookRegisterClient) UnregisterOwned(ctx context.Context, c *config.Config) (bool, error) {
+	owned, err := a.owned(ctx, c)
+	if owned == nil || err != nil {
+		return false, err
+	}
+	a.Logger.Infof("Starting to unregister owned MutatingWebhookConfiguration %s", c.Name)
+	err = a.client.MutatingWebhookConfigurations().Delete(ctx, c.Name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{UID: &owned.UID, ResourceVersion: &owned.ResourceVersion},
+	})
+	if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// owned returns the registered MutatingWebhookConfiguration if it's marked by OwnerAnnotation of this client.
+func (a *AdmissionWebhookRegisterClient) owned(ctx context.Context, c *config.Config) (*admissionv1.MutatingWebhookConfiguration, error) {
+	existing, err := a.client.MutatingWebhookConfigurations().Get(ctx, c.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if existing.Annotations[OwnerAnnotation] != a.owner {
+		return nil, nil
+	}
+	return existing, nil
 }
 
 // Unregister unregisters MutatingWebhookConfiguration based on passed config.Config
 func (a *AdmissionWebhookRegisterClient) Unregister(ctx context.Context, c *config.Config) error {
 	a.Logger.Infof("Starting to unregister MutatingWebhookConfiguration based config: %#v", c)
 	defer a.Logger.Infof("Unregister for config %#v is done", c)
-	a.once.Do(a.initializeClient)
 	return a.client.MutatingWebhookConfigurations().Delete(ctx, c.Name, metav1.DeleteOptions{})
 }
@@ -0,0 +1,166 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/config/configtest"
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/k8s"
+)
+
+func TestAdmissionWebhookRegisterClient_Register(t *testing.T) {
+	ctx := context.Background()
+	conf := configtest.New(t)
+	clientset := fake.NewSimpleClientset()
+	client := clientset.AdmissionregistrationV1().MutatingWebhookConfigurations()
+	first := k8s.NewAdmissionWebhookRegisterClient(zap.NewNop().Sugar(), clientset)
+	require.NoError(t, first.Register(ctx, conf))
+	existing, err := client.Get(ctx, conf.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	existing.Labels = map[string]string{"app": "admission-webhook-k8s"}
+	existing.Webhooks[0].ClientConfig.CABundle = []byte("another replica")
+	_, err = client.Update(ctx, existing, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	// the existing configuration is updated, the reconciler of another replica can't restore it in between
+	clientset.PrependReactor("delete", "mutatingwebhookconfigurations", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("unexpected delete")
+	})
+	// the configuration is created by another replica after the lookup
+	var looked bool
+	clientset.PrependReactor("get", "mutatingwebhookconfigurations", func(k8stesting.Action) (bool, runtime.Object, error) {
+		if looked {
+			return false, nil, nil
+		}
+		looked = true
+		return true, nil, apierrors.NewNotFound(schema.GroupResource{Resource: "mutatingwebhookconfigurations"}, conf.Name)
+	})
+	second := k8s.NewAdmissionWebhookRegisterClient(zap.NewNop().Sugar(), clientset)
+	require.NoError(t, second.Register(ctx, conf))
+	require.True(t, looked)
+
+	existing, err = client.Get(ctx, conf.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, second.Owner(), existing.Annotations[k8s.OwnerAnnotation])
+	require.Equal(t, "admission-webhook-k8s", existing.Labels["app"])
+	require.Equal(t, conf.GetOrResolveCABundle(), existing.Webhooks[0].ClientConfig.CABundle)
+}
+
+func TestAdmissionWebhookRegisterClient_Register_ServicePort(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("NSM_SERVICE_PORT", "8443")
+	t.Setenv("NSM_LISTEN_ON", ":8443")
+	conf := configtest.New(t)
+	clientset := fake.NewSimpleClientset()
+	require.NoError(t, k8s.NewAdmissionWebhookRegisterClient(zap.NewNop().Sugar(), clientset).Register(ctx, conf))
+
+	existing, err := clientset.AdmissionregistrationV1().MutatingWebhookConfigurations().Get(ctx, conf.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, existing.Webhooks, 1)
+	service := existing.Webhooks[0].ClientConfig.Service
+	require.Equal(t, conf.Namespace, service.Namespace)
+	require.Equal(t, conf.ServiceName, service.Name)
+	require.Equal(t, "/mutate", *service.Path)
+	require.Equal(t, int32(8443), *service.Port)
+}
+
+func TestAdmissionWebhookRegisterClient_IsOwned(t *testing.T) {
+	ctx := context.Background()
+	conf := configtest.New(t)
+	clientset := fake.NewSimpleClientset()
+	first := k8s.NewAdmissionWebhookRegisterClient(zap.NewNop().Sugar(), clientset)
+	second := k8s.NewAdmissionWebhookRegisterClient(zap.NewNop().Sugar(), clientset)
+	require.NotEqual(t, first.Owner(), second.Owner())
+
+	owned, err := first.IsOwned(ctx, conf)
+	require.NoError(t, err)
+	require.False(t, owned)
+
+	require.NoError(t, first.Register(ctx, conf))
+	owned, err = first.IsOwned(ctx, conf)
+	require.NoError(t, err)
+	require.True(t, owned)
+
+	// the replicas share the certificate, but the configuration is owned by the last registered one only
+	require.NoError(t, second.Register(ctx, conf))
+	owned, err = first.IsOwned(ctx, conf)
+	require.NoError(t, err)
+	require.False(t, owned)
+	owned, err = second.IsOwned(ctx, conf)
+	require.NoError(t, err)
+	require.True(t, owned)
+}
+
+func TestAdmissionWebhookRegisterClient_UnregisterOwned(t *testing.T) {
+	ctx := context.Background()
+	conf := configtest.New(t)
+	clientset := fake.NewSimpleClientset()
+	first := k8s.NewAdmissionWebhookRegisterClient(zap.NewNop().Sugar(), clientset)
+	second := k8s.NewAdmissionWebhookRegisterClient(zap.NewNop().Sugar(), clientset)
+
+	require.NoError(t, first.Register(ctx, conf))
+	require.NoError(t, second.Register(ctx, conf))
+	unregistered, err := first.UnregisterOwned(ctx, conf)
+	require.NoError(t, err)
+	require.False(t, unregistered)
+	_, err = clientset.AdmissionregistrationV1().MutatingWebhookConfigurations().Get(ctx, conf.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+
+	unregistered, err = second.UnregisterOwned(ctx, conf)
+	require.NoError(t, err)
+	require.True(t, unregistered)
+	_, err = clientset.AdmissionregistrationV1().MutatingWebhookConfigurations().Get(ctx, conf.Name, metav1.GetOptions{})
+	require.True(t, apierrors.IsNotFound(err))
+
+	unregistered, err = second.UnregisterOwned(ctx, conf)
+	require.NoError(t, err)
+	require.False(t, unregistered)
+}
+
+func TestAdmissionWebhookRegisterClient_CheckRegistered(t *testing.T) {
+	ctx := context.Background()
+	conf := configtest.New(t)
+	clientset := fake.NewSimpleClientset()
+	registerClient := k8s.NewAdmissionWebhookRegisterClient(zap.NewNop().Sugar(), clientset)
+	require.Error(t, registerClient.CheckRegistered(ctx, conf))
+
+	require.NoError(t, registerClient.Register(ctx, conf))
+	require.NoError(t, registerClient.CheckRegistered(ctx, conf))
+
+	client := clientset.AdmissionregistrationV1().MutatingWebhookConfigurations()
+	existing, err := client.Get(ctx, conf.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	existing.Webhooks[0].ClientConfig.CABundle = []byte("another replica")
+	_, err = client.Update(ctx, existing, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	require.Error(t, registerClient.CheckRegistered(ctx, conf))
+
+	require.NoError(t, registerClient.Unregister(ctx, conf))
+	require.Error(t, registerClient.CheckRegistered(ctx, conf))
+}
@@ -0,0 +1,39 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/discovery"
+)
+
+// nativeSidecarsVersion is the first k8s version with native sidecar containers enabled by default.
+var nativeSidecarsVersion = version.MustParseGeneric("1.29.0")
+
+// IsNativeSidecarsSupported checks whether the API server supports init containers with restartPolicy: Always.
+func IsNativeSidecarsSupported(d discovery.ServerVersionInterface) (bool, error) {
+	info, err := d.ServerVersion()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to get API server version")
+	}
+	v, err := version.ParseGeneric(info.GitVersion)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to parse API server version %s", info.GitVersion)
+	}
+	return v.AtLeast(nativeSidecarsVersion), nil
+}
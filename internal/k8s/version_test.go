@@ -0,0 +1,51 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/k8s"
+)
+
+func TestIsNativeSidecarsSupported(t *testing.T) {
+	for gitVersion, supported := range map[string]bool{
+		"v1.27.3":             false,
+		"v1.28.15":            false,
+		"v1.29.0":             true,
+		"v1.30.2-gke.1587003": true,
+		"v2.0.0":              true,
+	} {
+		t.Run(gitVersion, func(t *testing.T) {
+			discovery := fake.NewSimpleClientset().Discovery().(*fakediscovery.FakeDiscovery)
+			discovery.FakedServerVersion = &version.Info{GitVersion: gitVersion}
+			actual, err := k8s.IsNativeSidecarsSupported(discovery)
+			require.NoError(t, err)
+			require.Equal(t, supported, actual)
+		})
+	}
+
+	discovery := fake.NewSimpleClientset().Discovery().(*fakediscovery.FakeDiscovery)
+	discovery.FakedServerVersion = &version.Info{GitVersion: "unknown"}
+	_, err := k8s.IsNativeSidecarsSupported(discovery)
+	require.ErrorContains(t, err, "failed to parse API server version unknown")
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package podsecurity evaluates pods against the Pod Security Standards for cmd-admission-webhook-k8s
+package podsecurity
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+)
+
+// These are the modes of pod security admission.
+const (
+	// EnforceMode rejects pods violating the level.
+	EnforceMode = "enforce"
+	// AuditMode records violations in the audit log.
+	AuditMode = "audit"
+	// WarnMode returns violations as warnings to the user.
+	WarnMode = "warn"
+)
+
+const labelPrefix = "pod-security.kubernetes.io/"
+
+// Modes lists all modes of pod security admission.
+var Modes = []string{EnforceMode, AuditMode, WarnMode}
+
+// ParseDefaults parses the cluster default policy from a map of modes to levels and versions,
+// e.g. {"enforce": "baseline", "warn": "restricted", "warn-version": "v1.29"}. Not specified modes are privileged,
+// except warn which follows enforce like in pod security admission.
+func ParseDefaults(defaults map[string]string) (api.Policy, error) {
+	labels := make(map[string]string, len(defaults))
+	for key, value := range defaults {
+		labels[labelPrefix+key] = value
+	}
+	for key := range labels {
+		switch key {
+		case api.EnforceLevelLabel, api.EnforceVersionLabel, api.AuditLevelLabel, api.AuditVersionLabel, api.WarnLevelLabel, api.WarnVersionLabel:
+		default:
+			return api.Policy{}, errors.Errorf("not a valid pod security default: %s", strings.TrimPrefix(key, labelPrefix))
+		}
+	}
+	privileged := api.LevelVersion{Level: api.LevelPrivileged, Version: api.LatestVersion()}
+	policy, errs := api.PolicyToEvaluate(labels, api.Policy{Enforce: privileged, Audit: privileged, Warn: privileged})
+	if err := errs.ToAggregate(); err != nil {
+		return api.Policy{}, errors.Wrap(err, "failed to parse pod security defaults")
+	}
+	return policy, nil
+}
+
+// ValidateModes checks that all passed modes are pod security admission modes.
+func ValidateModes(modes []string) error {
+	for _, mode := range modes {
+		switch mode {
+		case EnforceMode, AuditMode, WarnMode:
+		default:
+			return errors.Errorf("not a valid pod security mode: %s", mode)
+		}
+	}
+	return nil
+}
+
+// PolicyOf returns the effective policy of the namespace from its PSA labels, falling back to the passed defaults.
+// A valid policy is always returned, invalid labels are reported by the error.
+func PolicyOf(namespace *corev1.Namespace, defaults *api.Policy) (api.Policy, error) {
+	if namespace == nil {
+		return *defaults, nil
+	}
+	policy, errs := api.PolicyToEvaluate(namespace.Labels, *defaults)
+	if err := errs.ToAggregate(); err != nil {
+		return policy, errors.Wrapf(err, "invalid PodSecurity labels of namespace %s", namespace.Name)
+	}
+	return policy, nil
+}
+
+// LevelVersionOf returns the level and version of the passed mode of the policy.
+func LevelVersionOf(policy *api.Policy, mode string) api.LevelVersion {
+	switch mode {
+	case EnforceMode:
+		return policy.Enforce
+	case AuditMode:
+		return policy.Audit
+	case WarnMode:
+		return policy.Warn
+	}
+	return api.LevelVersion{Level: api.LevelPrivileged, Version: api.LatestVersion()}
+}
+
+// StrictestLevel returns the strictest level among the passed modes of the policy.
+func StrictestLevel(policy *api.Policy, modes []string) api.Level {
+	level := api.LevelPrivileged
+	for _, mode := range modes {
+		if l := LevelVersionOf(policy, mode).Level; api.CompareLevels(l, level) > 0 {
+			level = l
+		}
+	}
+	return level
+}
+
+// CompactString returns the policy as "enforce=<level>:<version>, audit=<level>:<version>, warn=<level>:<version>".
+func CompactString(policy *api.Policy) string {
+	return fmt.Sprintf("enforce=%s, audit=%s, warn=%s", policy.Enforce.String(), policy.Audit.String(), policy.Warn.String())
+}
+
+// Evaluator checks pods using the default checks of k8s.io/pod-security-admission
+type Evaluator struct {
+	evaluator policy.Evaluator
+}
+
+// NewEvaluator creates Evaluator with the default pod security checks.
+func NewEvaluator() (*Evaluator, error) {
+	evaluator, err := policy.NewEvaluator(policy.DefaultChecks())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create pod security evaluator")
+	}
+	return &Evaluator{evaluator: evaluator}, nil
+}
+
+// Check evaluates the pod against the passed level and version. Returns an explanation of violations if the pod is not allowed.
+func (e *Evaluator) Check(lv api.LevelVersion, podMeta *metav1.ObjectMeta, podSpec *corev1.PodSpec) (allowed bool, explanation string) {
+	result := policy.AggregateCheckResults(e.evaluator.EvaluatePod(lv, podMeta, podSpec))
+	if result.Allowed {
+		return true, ""
+	}
+	return false, fmt.Sprintf("violates PodSecurity %q: %s", lv.String(), result.ForbiddenDetail())
+}
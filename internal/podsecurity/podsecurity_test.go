@@ -0,0 +1,156 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podsecurity_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/pod-security-admission/api"
+
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/podsecurity"
+)
+
+func TestEvaluator_Check(t *testing.T) {
+	evaluator, err := podsecurity.NewEvaluator()
+	require.NoError(t, err)
+	allowPrivilegeEscalation, runAsNonRoot, privileged := false, true, true
+	restricted := &corev1.SecurityContext{
+		AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+		RunAsNonRoot:             &runAsNonRoot,
+		Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+		SeccompProfile:           &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+	}
+	for _, tc := range []struct {
+		name            string
+		level           api.Level
+		securityContext *corev1.SecurityContext
+		violation       string
+	}{
+		{name: "privileged container in privileged level", level: api.LevelPrivileged, securityContext: &corev1.SecurityContext{Privileged: &privileged}},
+		{name: "privileged container in baseline level", level: api.LevelBaseline, securityContext: &corev1.SecurityContext{Privileged: &privileged}, violation: "privileged"},
+		{name: "default container in baseline level", level: api.LevelBaseline},
+		{name: "default container in restricted level", level: api.LevelRestricted, violation: "runAsNonRoot != true"},
+		{name: "restricted container in restricted level", level: api.LevelRestricted, securityContext: restricted},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			lv := api.LevelVersion{Level: tc.level, Version: api.LatestVersion()}
+			allowed, explanation := evaluator.Check(lv, &metav1.ObjectMeta{Name: "p"}, &corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "app", Image: "alpine", SecurityContext: tc.securityContext}},
+			})
+			if tc.violation == "" {
+				require.True(t, allowed, explanation)
+				require.Empty(t, explanation)
+				return
+			}
+			require.False(t, allowed)
+			require.Contains(t, explanation, `violates PodSecurity "`+lv.String()+`"`)
+			require.Contains(t, explanation, tc.violation)
+		})
+	}
+}
+
+func TestParseDefaults(t *testing.T) {
+	policy, err := podsecurity.ParseDefaults(nil)
+	require.NoError(t, err)
+	require.Equal(t, "enforce=privileged:latest, audit=privileged:latest, warn=privileged:latest", podsecurity.CompactString(&policy))
+
+	policy, err = podsecurity.ParseDefaults(map[string]string{"enforce": "baseline", "warn": "restricted", "warn-version": "v1.29"})
+	require.NoError(t, err)
+	require.Equal(t, "enforce=baseline:latest, audit=privileged:latest, warn=restricted:v1.29", podsecurity.CompactString(&policy))
+
+	policy, err = podsecurity.ParseDefaults(map[string]string{"enforce": "baseline"})
+	require.NoError(t, err)
+	require.Equal(t, "enforce=baseline:latest, audit=privileged:latest, warn=baseline:latest", podsecurity.CompactString(&policy))
+
+	_, err = podsecurity.ParseDefaults(map[string]string{"enforce": "strict"})
+	require.ErrorContains(t, err, "failed to parse pod security defaults")
+	_, err = podsecurity.ParseDefaults(map[string]string{"deny": "baseline"})
+	require.ErrorContains(t, err, "not a valid pod security default: deny")
+}
+
+func TestValidateModes(t *testing.T) {
+	require.NoError(t, podsecurity.ValidateModes(podsecurity.Modes))
+	require.NoError(t, podsecurity.ValidateModes(nil))
+	require.ErrorContains(t, podsecurity.ValidateModes([]string{"enforce", "deny"}), "not a valid pod security mode: deny")
+}
+
+func TestPolicyOf(t *testing.T) {
+	defaults, err := podsecurity.ParseDefaults(map[string]string{"enforce": "baseline", "audit": "restricted"})
+	require.NoError(t, err)
+	for _, tc := range []struct {
+		name      string
+		namespace *corev1.Namespace
+		policy    string
+		err       string
+	}{
+		{
+			name:   "no namespace",
+			policy: "enforce=baseline:latest, audit=restricted:latest, warn=baseline:latest",
+		},
+		{
+			name:      "namespace without labels",
+			namespace: &corev1.Namespace{},
+			policy:    "enforce=baseline:latest, audit=restricted:latest, warn=baseline:latest",
+		},
+		{
+			name: "namespace with labels",
+			namespace: &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+				"pod-security.kubernetes.io/enforce":         "privileged",
+				"pod-security.kubernetes.io/warn":            "restricted",
+				"pod-security.kubernetes.io/warn-version":    "v1.28",
+				"pod-security.kubernetes.io/enforce-version": "v1.27",
+			}}},
+			policy: "enforce=privileged:v1.27, audit=restricted:latest, warn=restricted:v1.28",
+		},
+		{
+			name: "namespace with invalid labels",
+			namespace: &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns", Labels: map[string]string{
+				"pod-security.kubernetes.io/enforce": "strict",
+			}}},
+			err: "invalid PodSecurity labels of namespace ns",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			policy, err := podsecurity.PolicyOf(tc.namespace, &defaults)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.policy, podsecurity.CompactString(&policy))
+		})
+	}
+}
+
+func TestStrictestLevel(t *testing.T) {
+	policy, err := podsecurity.ParseDefaults(map[string]string{"enforce": "privileged", "audit": "restricted", "warn": "baseline"})
+	require.NoError(t, err)
+	for _, tc := range []struct {
+		modes []string
+		level api.Level
+	}{
+		{modes: nil, level: api.LevelPrivileged},
+		{modes: []string{podsecurity.EnforceMode}, level: api.LevelPrivileged},
+		{modes: []string{podsecurity.EnforceMode, podsecurity.WarnMode}, level: api.LevelBaseline},
+		{modes: podsecurity.Modes, level: api.LevelRestricted},
+	} {
+		require.Equal(t, tc.level, podsecurity.StrictestLevel(&policy, tc.modes), tc.modes)
+	}
+}
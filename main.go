@@ -2,6 +2,8 @@
 //
 // Copyright (c) 2023-2024 Cisco and/or its affiliates.
 //
+// Copyright (c) 2026 Nordix Foundation.
+//
 // SPDX-License-Identifier: Apache-2.0
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
@@ -20,17 +22,24 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
+	"maps"
+	"mime"
 	"net/http"
-	"net/url"
 	"os"
 	"os/signal"
 	"path"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -44,18 +53,23 @@ import (
 	"go.uber.org/zap"
 	"gomodules.xyz/jsonpatch/v2"
 	admissionv1 "k8s.io/api/admission/v1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/kubernetes"
 	psa "k8s.io/pod-security-admission/api"
 
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/annotation"
 	"github.com/networkservicemesh/cmd-admission-webhook/internal/config"
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/health"
 	"github.com/networkservicemesh/cmd-admission-webhook/internal/k8s"
-	kubeutils "github.com/networkservicemesh/sdk-k8s/pkg/tools/k8s"
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/podsecurity"
 	"github.com/networkservicemesh/sdk/pkg/tools/nsurl"
 	"github.com/networkservicemesh/sdk/pkg/tools/opentelemetry"
 	"github.com/networkservicemesh/sdk/pkg/tools/pprofutils"
@@ -64,9 +78,94 @@ import (
 var deserializer = serializer.NewCodecFactory(runtime.NewScheme()).UniversalDeserializer()
 
 type admissionWebhookServer struct {
-	config    *config.Config
-	logger    *zap.SugaredLogger
-	clientset *kubernetes.Clientset
+	config              *config.Config
+	logger              *zap.SugaredLogger
+	clientset           kubernetes.Interface
+	podSecurity         *podsecurity.Evaluator
+	podSecurityDefaults psa.Policy
+	nativeSidecars      bool
+}
+
+// mutate returns the handler of AdmissionReview requests. Both admission.k8s.io/v1 and v1beta1 are accepted,
+// the request is decoded into v1 types and the response is sent in the version of the request.
+// Malformed reviews are answered with 200 and an AdmissionReview denying the admission with the status of the problem,
+// the API server ignores the response of any other code. Requests which can't carry a review at all are answered
+// with the code of the status.
+func (s *admissionWebhookServer) mutate() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var review = new(admissionv1.AdmissionReview)
+		review.SetGroupVersionKind(admissionv1.SchemeGroupVersion.WithKind("AdmissionReview"))
+		gvk, status := s.decodeReview(c, review)
+		if status != nil {
+			s.logger.Errorf("malformed admission request: %s", status.Message)
+			review.Request, review.Response = nil, &admissionv1.AdmissionResponse{Result: status}
+			return c.JSON(int(status.Code), review)
+		}
+		if status := validateReview(review, gvk); status != nil {
+			s.logger.Errorf("malformed admission review: %s", status.Message)
+			var resp = &admissionv1.AdmissionResponse{Result: status}
+			if review.Request != nil {
+				resp.UID = review.Request.UID
+			}
+			review.Request, review.Response = nil, resp
+			return c.JSON(http.StatusOK, review)
+		}
+
+		// the context of the request is used, it's not canceled on shutdown while admissions are drained
+		review.Response = s.Review(c.Request().Context(), review.Request)
+		return c.JSON(http.StatusOK, review)
+	}
+}
+
+// decodeReview decodes the request body into the review. Returns the failure status if the request can't be a review.
+func (s *admissionWebhookServer) decodeReview(c echo.Context, review *admissionv1.AdmissionReview) (*schema.GroupVersionKind, *v1.Status) {
+	if s.config.IsClientCertificateRequired() && (c.Request().TLS == nil || len(c.Request().TLS.VerifiedChains) == 0) {
+		return nil, failure(http.StatusUnauthorized, v1.StatusReasonUnauthorized, "verified client certificate is required")
+	}
+	mediaType, _, err := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	if err != nil || mediaType != echo.MIMEApplicationJSON {
+		return nil, failure(http.StatusUnsupportedMediaType, v1.StatusReasonUnsupportedMediaType,
+			fmt.Sprintf("content type must be %s", echo.MIMEApplicationJSON))
+	}
+	msg, err := io.ReadAll(http.MaxBytesReader(c.Response(), c.Request().Body, s.config.MaxRequestBodySize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, failure(http.StatusRequestEntityTooLarge, v1.StatusReasonRequestEntityTooLarge,
+				fmt.Sprintf("request body exceeds %d bytes", maxBytesErr.Limit))
+		}
+		return nil, failure(http.StatusBadRequest, v1.StatusReasonBadRequest, fmt.Sprintf("failed to read request body: %s", err.Error()))
+	}
+	_, gvk, err := deserializer.Decode(msg, nil, review)
+	if err != nil {
+		review.SetGroupVersionKind(admissionv1.SchemeGroupVersion.WithKind("AdmissionReview"))
+		return nil, failure(http.StatusBadRequest, v1.StatusReasonBadRequest, fmt.Sprintf("failed to decode AdmissionReview: %s", err.Error()))
+	}
+	return gvk, nil
+}
+
+// validateReview checks the kind and the version of the decoded review and sets the version of the response.
+// Returns the failure status if the review is malformed.
+func validateReview(review *admissionv1.AdmissionReview, gvk *schema.GroupVersionKind) *v1.Status {
+	if gv := gvk.GroupVersion(); gvk.Kind != "AdmissionReview" || gv != admissionv1.SchemeGroupVersion && gv != admissionv1beta1.SchemeGroupVersion {
+		review.SetGroupVersionKind(admissionv1.SchemeGroupVersion.WithKind("AdmissionReview"))
+		return failure(http.StatusBadRequest, v1.StatusReasonBadRequest, fmt.Sprintf("unsupported AdmissionReview kind: %s", gvk))
+	}
+	review.SetGroupVersionKind(*gvk)
+	if review.Request == nil {
+		return failure(http.StatusBadRequest, v1.StatusReasonBadRequest, "AdmissionReview has no request")
+	}
+	return nil
+}
+
+// failure returns the status of the denied admission.
+func failure(code int32, reason v1.StatusReason, message string) *v1.Status {
+	return &v1.Status{
+		Status:  v1.StatusFailure,
+		Message: message,
+		Reason:  reason,
+		Code:    code,
+	}
 }
 
 func (s *admissionWebhookServer) Review(ctx context.Context, in *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
@@ -84,12 +183,7 @@ func (s *admissionWebhookServer) Review(ctx context.Context, in *admissionv1.Adm
 		resp.Allowed = true
 		return resp
 	}
-	podMetaPtr, spec := s.unmarshal(in)
-	p := ""
-	if in.Kind.Kind != "Pod" {
-		p = "/spec/template"
-	}
-	p = path.Join("/", p)
+	res, reason := s.unmarshal(in)
 
 	timeoutCtx, cancel := context.WithTimeout(ctx, time.Second)
 	defer cancel()
@@ -98,143 +192,539 @@ func (s *admissionWebhookServer) Review(ctx context.Context, in *admissionv1.Adm
 		s.logger.Errorf("failed to get namespace by name: %v", err)
 	}
 
-	if spec == nil && podMetaPtr == nil {
+	if reason == skippedMalformedSpecification && s.hasNSMAnnotations(res.podMeta.Annotations) {
+		return s.deferToPods(resp, res)
+	}
+	if reason != "" {
+		if !s.isAnnotated(in) {
+			// the resource isn't related to NSM, e.g. a workload with its own pod template annotations
+			resp.Allowed = true
+			return resp
+		}
+		return s.skip(resp, res, reason)
+	}
+	if in.Kind.Kind == "Pod" && res.podMeta.Annotations[s.config.StatusAnnotation] == statusInjected {
+		// the pod is created from the already injected template of a workload
 		resp.Allowed = true
 		return resp
 	}
-	annotation := podMetaPtr.Annotations[s.config.Annotation]
-
-	if annotation == "" && in.Kind.Kind != "Pod" {
-		resp.Allowed = true
+	if s.config.AnnotationMergeStrategy == config.NamespaceOnlyMergeStrategy && res.podMeta.Annotations[s.config.Annotation] != "" {
+		resp.Warnings = append(resp.Warnings, "the network service annotation of the resource is ignored, only the namespace annotation is used")
+	}
+	requests, err := s.networkServicesOf(in, res, namespace)
+	if err != nil {
+		resp.Result = failure(http.StatusBadRequest, v1.StatusReasonInvalid, err.Error())
 		return resp
 	}
-
-	// use namespace annotation only if resource doesn't have its own
-	if annotation == "" && in.Kind.Kind == "Pod" && namespace != nil {
-		annotation = namespace.Annotations[s.config.Annotation]
+	if len(requests) == 0 {
+		return s.skipNotAnnotated(resp, res)
 	}
 
-	if annotation != "" {
-		nsmNameEnv := corev1.EnvVar{Name: "NSM_NAME", Value: "$(POD_NAME)"}
-		if podMetaPtr.GenerateName == "" {
-			clientID := uuid.NewString()
-			nsmNameEnv.Value = fmt.Sprintf("$(POD_NAME)-%v", clientID)
+	return s.inject(resp, in, res, namespace, requests)
+}
+
+// networkServicesOf returns the network services requested by the NS annotation of the resource combined with
+// the NS annotation of the namespace according to Config.AnnotationMergeStrategy. Placeholders of both annotations
+// are expanded, {{index}} counts the network services of the namespace first.
+func (s *admissionWebhookServer) networkServicesOf(in *admissionv1.AdmissionRequest, res *admittedResource, namespace *corev1.Namespace) ([]*annotation.Request, error) {
+	var namespaceValue string
+	if namespace != nil {
+		namespaceValue = namespace.Annotations[s.config.Annotation]
+	}
+	resourceValue := res.podMeta.Annotations[s.config.Annotation]
+	switch s.config.AnnotationMergeStrategy {
+	case config.OverrideMergeStrategy:
+		if resourceValue != "" {
+			namespaceValue = ""
 		}
-		envVars := append(s.config.GetOrResolveEnvs(),
-			corev1.EnvVar{Name: s.config.NSURLEnvName, Value: annotation},
-			nsmNameEnv)
-
-		psaLevel := psaLevelByNamespace(namespace)
-		bytes, err := json.Marshal([]jsonpatch.JsonPatchOperation{
-			s.createInitContainerPatch(p, annotation, spec.InitContainers, psaLevel, envVars...),
-			s.createContainerPatch(p, spec.Containers, psaLevel, envVars...),
-			s.createVolumesPatch(p, spec.Volumes, psaLevel),
-			s.createLabelPatch(p, podMetaPtr.Labels),
+	case config.NamespaceOnlyMergeStrategy:
+		resourceValue = ""
+	}
+	placeholders := &annotation.Placeholders{
+		Namespace: in.Namespace,
+		Workload:  res.meta.Name,
+		Labels:    res.podMeta.Labels,
+		Index:     1,
+	}
+	if placeholders.Workload == "" {
+		placeholders.Workload = strings.TrimSuffix(res.meta.GenerateName, "-")
+	}
+	namespaceRequests, err := annotation.Parse(namespaceValue, placeholders)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid NS annotation of namespace %s", namespace.Name)
+	}
+	placeholders.Index += len(namespaceRequests)
+	resourceRequests, err := annotation.Parse(resourceValue, placeholders)
+	if err != nil {
+		return nil, err
+	}
+	return annotation.Union(namespaceRequests, resourceRequests), nil
+}
+
+// inject mutates the resource with NSM containers, volumes, labels and the status annotation.
+func (s *admissionWebhookServer) inject(resp *admissionv1.AdmissionResponse, in *admissionv1.AdmissionRequest, res *admittedResource,
+	namespace *corev1.Namespace, requests []*annotation.Request) *admissionv1.AdmissionResponse {
+	podMetaPtr, spec := res.podMeta, res.podSpec
+	nsURLs, serviceResources := annotation.Render(requests)
+	profile, ok := s.profileOf(podMetaPtr, namespace)
+	if !ok {
+		resp.Result = failure(http.StatusBadRequest, v1.StatusReasonInvalid,
+			fmt.Sprintf("unknown injection profile: %s", s.profileNameOf(podMetaPtr, namespace)))
+		return resp
+	}
+	nsmName, err := s.nsmNameOf(in, res)
+	if err != nil {
+		resp.Result = failure(http.StatusInternalServerError, v1.StatusReasonInternalError, err.Error())
+		return resp
+	}
+	nsmNameEnv := corev1.EnvVar{Name: "NSM_NAME", Value: nsmName}
+	envVars := append([]corev1.EnvVar(nil), profile.GetEnvs()...)
+	if strings.Contains(nsURLs, annotation.NodeNameEnvRef) {
+		envVars = append(envVars, corev1.EnvVar{
+			Name: "NODE_NAME",
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{FieldPath: "spec.nodeName"},
+			},
 		})
-		if err != nil {
-			resp.Result = &v1.Status{
-				Status: err.Error(),
-			}
+	}
+	envVars = append(envVars,
+		corev1.EnvVar{Name: s.config.NSURLEnvName, Value: nsURLs},
+		nsmNameEnv)
+
+	policy, err := podsecurity.PolicyOf(namespace, &s.podSecurityDefaults)
+	if err != nil {
+		resp.Warnings = append(resp.Warnings, err.Error())
+	}
+	psaLevel := podsecurity.StrictestLevel(&policy, s.config.PodSecurityModes)
+	if psaLevel != policy.Enforce.Level {
+		resp.Warnings = append(resp.Warnings,
+			fmt.Sprintf("NSM containers are configured for PodSecurity level %q because of the namespace policy %s", psaLevel, podsecurity.CompactString(&policy)))
+	}
+	templateData := &config.TemplateData{
+		PodName:    podMetaPtr.Name,
+		Namespace:  in.Namespace,
+		Annotation: nsURLs,
+		Envs:       envValues(envVars),
+	}
+	resources := parseResources(requests, s.config.GetOrResolveResourceRules())
+	resources = addServiceResources(resources, serviceResources)
+	if err := s.checkResourceClaims(resources); err != nil {
+		resp.Result = failure(http.StatusBadRequest, v1.StatusReasonInvalid, err.Error())
+		return resp
+	}
+	mutated, err := s.mutatePodSpec(spec, resources, profile, psaLevel, templateData, envVars...)
+	if err != nil {
+		resp.Result = failure(http.StatusInternalServerError, v1.StatusReasonInternalError, err.Error())
+		return resp
+	}
+	violation, warnings := s.checkPodSecurity(&policy, podMetaPtr, spec, mutated)
+	resp.Warnings = append(resp.Warnings, warnings...)
+	if violation != "" {
+		if s.config.PodSecurityViolationPolicy == config.DenyViolationPolicy {
+			resp.Result = failure(http.StatusForbidden, v1.StatusReasonForbidden, violation)
 			return resp
 		}
-		resp.Patch = bytes
-		var t = admissionv1.PatchTypeJSONPatch
-		resp.PatchType = &t
+		resp.Warnings = append(resp.Warnings, violation)
+	}
+	*spec = *mutated
+	s.addLabels(podMetaPtr, profile)
+	s.setStatusAnnotation(res.meta, statusInjected)
+	if res.inheritedAnnotations {
+		// pods created from the template must not be injected again
+		res.podAnnotations = map[string]string{s.config.StatusAnnotation: statusInjected}
 	}
+	bytes, err := res.patch()
+	if err != nil {
+		resp.Result = failure(http.StatusInternalServerError, v1.StatusReasonInternalError,
+			fmt.Sprintf("NSM injection failed: %s", err.Error()))
+		return resp
+	}
+	resp.Patch = bytes
+	var t = admissionv1.PatchTypeJSONPatch
+	resp.PatchType = &t
 
 	resp.Allowed = true
 	return resp
 }
 
-func psaLevelByNamespace(namespace *corev1.Namespace) psa.Level {
-	if namespace == nil {
-		return psa.LevelPrivileged
+// nameHashLength is the number of hex digits of the hash appended to NSM_NAME.
+const nameHashLength = 10
+
+// nsmNameOf returns the value of NSM_NAME env according to Config.NameStrategy.
+// Pods using generateName have unique names, so the pod name is used for them.
+func (s *admissionWebhookServer) nsmNameOf(in *admissionv1.AdmissionRequest, res *admittedResource) (string, error) {
+	if res.podMeta.GenerateName != "" {
+		return annotation.PodNameEnvRef, nil
+	}
+	data := &config.NameData{
+		Kind:      res.kind,
+		Namespace: in.Namespace,
+		Name:      res.meta.Name,
+		UID:       string(res.meta.UID),
+	}
+	sum := sha256.Sum256([]byte(strings.Join([]string{data.Kind, data.Namespace, data.Name, data.UID}, "/")))
+	data.Hash = hex.EncodeToString(sum[:])[:nameHashLength]
+	switch s.config.NameStrategy {
+	case config.RandomNameStrategy:
+		return fmt.Sprintf("%s-%v", annotation.PodNameEnvRef, uuid.NewString()), nil
+	case config.PodNameStrategy:
+		return annotation.PodNameEnvRef, nil
+	case config.TemplateNameStrategy:
+		return s.config.GetOrResolveNameTemplate().Render(data)
+	default:
+		return fmt.Sprintf("%s-%s", annotation.PodNameEnvRef, data.Hash), nil
+	}
+}
+
+// mutatePodSpec returns a copy of the pod spec with injected NSM containers and volumes.
+func (s *admissionWebhookServer) mutatePodSpec(spec *corev1.PodSpec, resources map[string]corev1.ResourceList, profile *config.Profile, psaLevel psa.Level, data *config.TemplateData, envVars ...corev1.EnvVar) (*corev1.PodSpec, error) {
+	mutated := spec.DeepCopy()
+	initContainers, err := s.createInitContainers(profile, psaLevel, data, envVars...)
+	if err != nil {
+		return nil, err
+	}
+	sidecars, err := s.createContainers(profile, psaLevel, data, envVars...)
+	if err != nil {
+		return nil, err
+	}
+	mutated.ResourceClaims = append(mutated.ResourceClaims, s.createResourceClaims(initContainers, sidecars, resources)...)
+	addDeviceResources(initContainers, sidecars, resources)
+	mutated.InitContainers, mutated.Containers = s.arrangeContainers(mutated, initContainers, sidecars)
+	mutated.Volumes = s.createVolumes(mutated.Volumes, profile, psaLevel)
+	return mutated, nil
+}
+
+// checkPodSecurity evaluates the mutated pod against all modes of the namespace policy.
+// Returns the explanation of the enforce level violation and warnings for the audit and warn levels violations.
+func (s *admissionWebhookServer) checkPodSecurity(policy *psa.Policy, podMetaPtr *v1.ObjectMeta, spec, mutated *corev1.PodSpec) (violation string, warnings []string) {
+	for _, mode := range podsecurity.Modes {
+		explanation := s.explainPodSecurity(podsecurity.LevelVersionOf(policy, mode), podMetaPtr, spec, mutated)
+		switch {
+		case explanation == "":
+		case mode == podsecurity.EnforceMode:
+			violation = explanation
+		default:
+			warnings = append(warnings, fmt.Sprintf("%s: %s", mode, explanation))
+		}
+	}
+	return violation, warnings
+}
+
+// explainPodSecurity evaluates the mutated pod against the level and explains whether the resource itself or the injected NSM containers violate it.
+func (s *admissionWebhookServer) explainPodSecurity(lv psa.LevelVersion, podMetaPtr *v1.ObjectMeta, spec, mutated *corev1.PodSpec) string {
+	if lv.Level == psa.LevelPrivileged {
+		return ""
+	}
+	allowed, explanation := s.podSecurity.Check(lv, podMetaPtr, mutated)
+	if allowed {
+		return ""
+	}
+	if ok, _ := s.podSecurity.Check(lv, podMetaPtr, spec); !ok {
+		return fmt.Sprintf("resource %s regardless of injected NSM containers", explanation)
+	}
+	return fmt.Sprintf("resource with injected NSM containers %s, check the admission webhook configuration", explanation)
+}
+
+// profileNameOf returns the name of the injection profile selected by the resource annotation,
+// falling back to the namespace annotation. Empty name means the default profile.
+func (s *admissionWebhookServer) profileNameOf(podMetaPtr *v1.ObjectMeta, namespace *corev1.Namespace) string {
+	if name := podMetaPtr.Annotations[s.config.ProfileAnnotation]; name != "" {
+		return name
+	}
+	if namespace != nil {
+		return namespace.Annotations[s.config.ProfileAnnotation]
+	}
+	return ""
+}
+
+func (s *admissionWebhookServer) profileOf(podMetaPtr *v1.ObjectMeta, namespace *corev1.Namespace) (*config.Profile, bool) {
+	return s.config.GetOrResolveProfile(s.profileNameOf(podMetaPtr, namespace))
+}
+
+// admittedResource is the decoded resource of the admission request with pointers to its pod template.
+type admittedResource struct {
+	kind     string
+	object   interface{}
+	original []byte
+	meta     *v1.ObjectMeta
+	podMeta  *v1.ObjectMeta
+	podSpec  *corev1.PodSpec
+	// inheritedAnnotations is set when annotations of the pod template are taken from the resource metadata.
+	inheritedAnnotations bool
+	// podAnnotations replace the inherited annotations of the pod template in the patch.
+	podAnnotations map[string]string
+}
+
+// patch creates a minimal JSON patch from the decoded resource to the mutated one.
+// Only the changes made by the webhook are present in the patch, so changes of other mutating webhooks are preserved.
+func (r *admittedResource) patch() ([]byte, error) {
+	if r.inheritedAnnotations {
+		r.podMeta.Annotations = r.podAnnotations
+	}
+	mutated, err := json.Marshal(r.object)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal mutated %s", r.kind)
 	}
-	level, err := psa.ParseLevel(namespace.Labels[psa.EnforceLevelLabel])
+	patch, err := jsonpatch.CreatePatch(r.original, mutated)
 	if err != nil {
-		return psa.LevelPrivileged
+		return nil, errors.Wrapf(err, "failed to create patch for %s", r.kind)
+	}
+	var original interface{}
+	if err := json.Unmarshal(r.original, &original); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode %s", r.kind)
+	}
+	sortPatch(patch, original, 1)
+	return json.Marshal(patch)
+}
+
+// sortPatch orders the operations of the object members by their keys, so identical requests get identical patches
+// regardless of the map iteration order of jsonpatch.CreatePatch. The operations of each member are contiguous and
+// independent of the other members, while the order of the operations of array elements matters and is kept.
+// node is the original value at the common prefix of the operations, depth is the index of the next path segment.
+func sortPatch(patch []jsonpatch.Operation, node interface{}, depth int) {
+	var runs [][]jsonpatch.Operation
+	for i := range patch {
+		if len(runs) == 0 || pathSegment(patch[i].Path, depth) != pathSegment(runs[len(runs)-1][0].Path, depth) {
+			runs = append(runs, nil)
+		}
+		runs[len(runs)-1] = append(runs[len(runs)-1], patch[i])
+	}
+	if _, ok := node.(map[string]interface{}); ok {
+		sort.SliceStable(runs, func(i, j int) bool {
+			return pathSegment(runs[i][0].Path, depth) < pathSegment(runs[j][0].Path, depth)
+		})
+	}
+	sorted := patch[:0:0]
+	for _, run := range runs {
+		if child, ok := childOf(node, pathSegment(run[0].Path, depth)); ok && isNested(run, depth) {
+			sortPatch(run, child, depth+1)
+		}
+		sorted = append(sorted, run...)
+	}
+	copy(patch, sorted)
+}
+
+// pathSegment returns the segment of the JSON pointer with the index, the leading empty segment has index 0.
+func pathSegment(path string, index int) string {
+	segments := strings.Split(path, "/")
+	if index >= len(segments) {
+		return ""
+	}
+	return segments[index]
+}
+
+// isNested checks whether all operations change descendants of the segment rather than the segment itself.
+func isNested(patch []jsonpatch.Operation, depth int) bool {
+	for i := range patch {
+		if strings.Count(patch[i].Path, "/") <= depth {
+			return false
+		}
+	}
+	return true
+}
+
+// childOf returns the member of the object or the element of the array referenced by the JSON pointer segment.
+func childOf(node interface{}, segment string) (interface{}, bool) {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[strings.NewReplacer("~1", "/", "~0", "~").Replace(segment)]
+		return child, ok
+	case []interface{}:
+		i, err := strconv.Atoi(segment)
+		if err != nil || i < 0 || i >= len(n) {
+			return nil, false
+		}
+		return n[i], true
 	}
-	return level
+	return nil, false
 }
 
-func (s *admissionWebhookServer) unmarshal(in *admissionv1.AdmissionRequest) (podMetaPtr *v1.ObjectMeta, podSpec *corev1.PodSpec) {
-	var target interface{}
-	var metaPtr *v1.ObjectMeta
+func (s *admissionWebhookServer) unmarshal(in *admissionv1.AdmissionRequest) (res *admittedResource, reason string) {
+	res = &admittedResource{kind: in.Kind.Kind}
 	switch in.Kind.Kind {
 	case "Deployment":
 		var deployment appsv1.Deployment
-		metaPtr = &deployment.ObjectMeta
-		podMetaPtr = &deployment.Spec.Template.ObjectMeta
-		podSpec = &deployment.Spec.Template.Spec
-		target = &deployment
+		res.meta = &deployment.ObjectMeta
+		res.podMeta = &deployment.Spec.Template.ObjectMeta
+		res.podSpec = &deployment.Spec.Template.Spec
+		res.object = &deployment
 	case "Pod":
 		var pod corev1.Pod
-		podMetaPtr = &pod.ObjectMeta
-		podSpec = &pod.Spec
-		target = &pod
+		res.meta = &pod.ObjectMeta
+		res.podMeta = &pod.ObjectMeta
+		res.podSpec = &pod.Spec
+		res.object = &pod
 	case "DaemonSet":
 		var daemonSet appsv1.DaemonSet
-		metaPtr = &daemonSet.ObjectMeta
-		podMetaPtr = &daemonSet.Spec.Template.ObjectMeta
-		podSpec = &daemonSet.Spec.Template.Spec
-		target = &daemonSet
+		res.meta = &daemonSet.ObjectMeta
+		res.podMeta = &daemonSet.Spec.Template.ObjectMeta
+		res.podSpec = &daemonSet.Spec.Template.Spec
+		res.object = &daemonSet
 	case "StatefulSet":
 		var statefulSet appsv1.StatefulSet
-		metaPtr = &statefulSet.ObjectMeta
-		podMetaPtr = &statefulSet.Spec.Template.ObjectMeta
-		podSpec = &statefulSet.Spec.Template.Spec
-		target = &statefulSet
+		res.meta = &statefulSet.ObjectMeta
+		res.podMeta = &statefulSet.Spec.Template.ObjectMeta
+		res.podSpec = &statefulSet.Spec.Template.Spec
+		res.object = &statefulSet
 	case "ReplicaSet":
 		var replicaSet appsv1.ReplicaSet
-		metaPtr = &replicaSet.ObjectMeta
-		podMetaPtr = &replicaSet.Spec.Template.ObjectMeta
-		podSpec = &replicaSet.Spec.Template.Spec
-		target = &replicaSet
+		res.meta = &replicaSet.ObjectMeta
+		res.podMeta = &replicaSet.Spec.Template.ObjectMeta
+		res.podSpec = &replicaSet.Spec.Template.Spec
+		res.object = &replicaSet
 	default:
-		return nil, nil
+		return nil, skippedUnsupportedKind
 	}
-	if err := json.Unmarshal(in.Object.Raw, target); err != nil {
-		return nil, nil
+	if err := json.Unmarshal(in.Object.Raw, res.object); err != nil {
+		s.logger.Errorf("failed to decode %s: %v", in.Kind.Kind, err)
+		return nil, skippedDecodeError
 	}
-	podMetaPtr = s.postProcessPodMeta(podMetaPtr, metaPtr, in.Kind.Kind)
-	if podMetaPtr == nil {
-		return nil, nil
+	// The patch is created against the decoded resource, so the fields unknown to the webhook are not touched.
+	original, err := json.Marshal(res.object)
+	if err != nil {
+		s.logger.Errorf("failed to marshal %s: %v", in.Kind.Kind, err)
+		return nil, skippedDecodeError
+	}
+	res.original = original
+	res.inheritedAnnotations = in.Kind.Kind != "Pod" && res.podMeta.Annotations == nil
+	switch reason = s.postProcessPodMeta(res.podMeta, res.meta, in.Kind.Kind); reason {
+	case "":
+		return res, ""
+	case skippedOwnedByDeployment:
+		// ReplicaSets of Deployments are managed by the Deployment controller and must not be mutated.
+		return nil, reason
+	default:
+		return res, reason
 	}
-	return podMetaPtr, podSpec
 }
 
-func (s *admissionWebhookServer) postProcessPodMeta(podMetaPtr, metaPtr *v1.ObjectMeta, kind string) *v1.ObjectMeta {
+func (s *admissionWebhookServer) postProcessPodMeta(podMetaPtr, metaPtr *v1.ObjectMeta, kind string) (reason string) {
 	if podMetaPtr.Labels == nil {
 		podMetaPtr.Labels = make(map[string]string)
 	}
+	// ReplicaSets of Deployments are checked first, their templates have annotations of the injected Deployment.
+	if kind == "ReplicaSet" {
+		for _, o := range metaPtr.OwnerReferences {
+			if o.Kind == "Deployment" {
+				return skippedOwnedByDeployment
+			}
+		}
+	}
 	// Annotations shouldn't be applied second time.
 	if kind != "Pod" {
 		if podMetaPtr.Annotations == nil {
 			podMetaPtr.Annotations = metaPtr.Annotations
 		} else {
-			s.logger.Errorf("Malformed specification. Annotations can't be provided in several places.")
-			return nil
+			return skippedMalformedSpecification
 		}
 	}
-	if kind == "ReplicaSet" {
-		for _, o := range metaPtr.OwnerReferences {
-			if o.Kind == "Deployment" {
-				return nil
-			}
-		}
+	return ""
+}
+
+// isAnnotated checks whether the metadata or the pod template of the resource has Config.Annotation or
+// Config.ProfileAnnotation. The raw object is decoded, so it works for the resources the webhook can't handle.
+func (s *admissionWebhookServer) isAnnotated(in *admissionv1.AdmissionRequest) bool {
+	var object struct {
+		Metadata v1.ObjectMeta `json:"metadata"`
+		Spec     struct {
+			Template struct {
+				Metadata v1.ObjectMeta `json:"metadata"`
+			} `json:"template"`
+		} `json:"spec"`
+	}
+	// the object may be malformed, the decoded part is checked then
+	_ = json.Unmarshal(in.Object.Raw, &object)
+	return s.hasNSMAnnotations(object.Metadata.Annotations) || s.hasNSMAnnotations(object.Spec.Template.Metadata.Annotations)
+}
+
+// hasNSMAnnotations checks whether the annotations contain Config.Annotation or Config.ProfileAnnotation.
+func (s *admissionWebhookServer) hasNSMAnnotations(annotations map[string]string) bool {
+	return annotations[s.config.Annotation] != "" || annotations[s.config.ProfileAnnotation] != ""
+}
+
+// These are the injection status values reported by the status annotation.
+const (
+	statusInjected = "injected"
+	statusSkipped  = "skipped"
+	statusDeferred = "deferred"
+)
+
+// These are the reasons of skipped injection reported by the status annotation and admission warnings.
+const (
+	skippedUnsupportedKind        = "unsupported-kind"
+	skippedDecodeError            = "decode-error"
+	skippedMalformedSpecification = "malformed-specification"
+	skippedOwnedByDeployment      = "owned-by-deployment"
+	skippedNoAnnotation           = "no-annotation"
+)
+
+var skipExplanations = map[string]string{
+	skippedUnsupportedKind:        "the resource kind is not supported",
+	skippedDecodeError:            "the resource can't be decoded",
+	skippedMalformedSpecification: "annotations of the pod template must be empty, the webhook takes them from the resource metadata",
+	skippedOwnedByDeployment:      "the ReplicaSet is owned by a Deployment that is handled instead",
+	skippedNoAnnotation:           "the resource has no network service annotation",
+}
+
+// skip allows the resource without injection and explains the reason by the admission warning.
+// The status annotation is added to the resource if res is passed.
+func (s *admissionWebhookServer) skip(resp *admissionv1.AdmissionResponse, res *admittedResource, reason string) *admissionv1.AdmissionResponse {
+	s.logger.Infof("Injection skipped: %s", reason)
+	resp.Allowed = true
+	resp.Warnings = append(resp.Warnings, fmt.Sprintf("NSM injection skipped: %s", skipExplanations[reason]))
+	if res == nil {
+		return resp
+	}
+	s.setStatusAnnotation(res.meta, fmt.Sprintf("%s:%s", statusSkipped, reason))
+	bytes, err := res.patch()
+	if err != nil {
+		s.logger.Errorf("failed to create status annotation patch: %v", err)
+		return resp
+	}
+	resp.Patch = bytes
+	var t = admissionv1.PatchTypeJSONPatch
+	resp.PatchType = &t
+	return resp
+}
+
+// deferToPods allows the workload whose pod template has its own NSM annotations without injection. The pods created
+// from the template are injected on their admission, it's explained by the admission warning and the status annotation.
+func (s *admissionWebhookServer) deferToPods(resp *admissionv1.AdmissionResponse, res *admittedResource) *admissionv1.AdmissionResponse {
+	s.logger.Infof("Injection deferred to pod admission")
+	resp.Allowed = true
+	resp.Warnings = append(resp.Warnings,
+		"NSM injection deferred: the pod template has its own NSM annotations, NSM containers are injected into the pods on their admission")
+	s.setStatusAnnotation(res.meta, statusDeferred)
+	bytes, err := res.patch()
+	if err != nil {
+		s.logger.Errorf("failed to create status annotation patch: %v", err)
+		return resp
+	}
+	resp.Patch = bytes
+	var t = admissionv1.PatchTypeJSONPatch
+	resp.PatchType = &t
+	return resp
+}
+
+// skipNotAnnotated explains the skipped injection only for resources that select an injection profile,
+// other resources are not related to NSM and are allowed silently.
+func (s *admissionWebhookServer) skipNotAnnotated(resp *admissionv1.AdmissionResponse, res *admittedResource) *admissionv1.AdmissionResponse {
+	if res.podMeta.Annotations[s.config.ProfileAnnotation] != "" {
+		return s.skip(resp, res, skippedNoAnnotation)
 	}
-	return podMetaPtr
+	resp.Allowed = true
+	return resp
 }
 
-func (s *admissionWebhookServer) createVolumesPatch(p string, volumes []corev1.Volume, psaLevel psa.Level) jsonpatch.JsonPatchOperation {
-	if psaLevel != psa.LevelPrivileged {
+func (s *admissionWebhookServer) createVolumes(volumes []corev1.Volume, profile *config.Profile, psaLevel psa.Level) []corev1.Volume {
+	if s.isCSIVolumesUsed(psaLevel) {
 		readOnly := true
 		volumes = append(volumes,
 			corev1.Volume{
 				Name: "spire-agent-socket",
 				VolumeSource: corev1.VolumeSource{
 					CSI: &corev1.CSIVolumeSource{
-						Driver:   "csi.spiffe.io",
+						Driver:   s.config.SpireSocketCSIDriver,
 						ReadOnly: &readOnly,
 					},
 				},
@@ -243,7 +733,7 @@ func (s *admissionWebhookServer) createVolumesPatch(p string, volumes []corev1.V
 				Name: "nsm-socket",
 				VolumeSource: corev1.VolumeSource{
 					CSI: &corev1.CSIVolumeSource{
-						Driver:   "csi.networkservicemesh.io",
+						Driver:   s.config.NSMSocketCSIDriver,
 						ReadOnly: &readOnly,
 					},
 				},
@@ -256,7 +746,7 @@ func (s *admissionWebhookServer) createVolumesPatch(p string, volumes []corev1.V
 				Name: "spire-agent-socket",
 				VolumeSource: corev1.VolumeSource{
 					HostPath: &corev1.HostPathVolumeSource{
-						Path: "/run/spire/sockets",
+						Path: s.config.SpireSocketHostPath,
 						Type: &hostPathDir,
 					},
 				},
@@ -265,136 +755,354 @@ func (s *admissionWebhookServer) createVolumesPatch(p string, volumes []corev1.V
 				Name: "nsm-socket",
 				VolumeSource: corev1.VolumeSource{
 					HostPath: &corev1.HostPathVolumeSource{
-						Path: "/var/lib/networkservicemesh",
+						Path: s.config.NSMSocketHostPath,
 						Type: &hostPathDir,
 					},
 				},
 			},
 		)
 	}
-	return jsonpatch.NewOperation("add", path.Join(p, "spec", "volumes"), volumes)
+	return append(volumes, profile.Volumes...)
 }
 
-func parseResources(v string, logger *zap.SugaredLogger) map[string]int {
-	var nsmURLs []*nsurl.NSURL
-	poolResources := make(map[string]int)
+func (s *admissionWebhookServer) isCSIVolumesUsed(psaLevel psa.Level) bool {
+	switch s.config.SocketVolumeType {
+	case config.CSIVolumeType:
+		return true
+	case config.HostPathVolumeType:
+		return false
+	default:
+		return psaLevel != psa.LevelPrivileged
+	}
+}
 
-	for _, rawURL := range strings.Split(v, ",") {
-		u, err := url.Parse(rawURL)
+// parseResources computes the resources requested by the NS annotation using the resource rules.
+// The resources are grouped by the target containers of the rules.
+func parseResources(requests []*annotation.Request, rules []*config.ResourceRule) map[string]corev1.ResourceList {
+	resources := make(map[string]corev1.ResourceList)
 
-		if err != nil {
-			logger.Errorf("Malformed NS annotation: %+v", rawURL)
-			return nil
+	for _, r := range requests {
+		for _, rule := range rules {
+			for name, quantity := range rule.Resources((*nsurl.NSURL)(r.URL)) {
+				if resources[rule.Target] == nil {
+					resources[rule.Target] = make(corev1.ResourceList)
+				}
+				q := resources[rule.Target][name]
+				q.Add(quantity)
+				resources[rule.Target][name] = q
+			}
+		}
+	}
+
+	return resources
+}
+
+// addServiceResources adds the resources requested by the structured NS annotation to the auto target containers.
+func addServiceResources(resources map[string]corev1.ResourceList, serviceResources corev1.ResourceList) map[string]corev1.ResourceList {
+	if len(serviceResources) == 0 {
+		return resources
+	}
+	if resources == nil {
+		resources = make(map[string]corev1.ResourceList)
+	}
+	if resources[config.AutoResourceTarget] == nil {
+		resources[config.AutoResourceTarget] = make(corev1.ResourceList)
+	}
+	for name, quantity := range serviceResources {
+		q := resources[config.AutoResourceTarget][name]
+		q.Add(quantity)
+		resources[config.AutoResourceTarget][name] = q
+	}
+	return resources
+}
+
+// createInitContainers returns NSM init containers that should be injected into the pod.
+func (s *admissionWebhookServer) createInitContainers(profile *config.Profile, psaLevel psa.Level, data *config.TemplateData, envVars ...corev1.EnvVar) ([]corev1.Container, error) {
+	injected, err := injectedContainers(profile.InitContainerImages, profile.GetInitContainerTemplates(), data)
+	if err != nil {
+		return nil, err
+	}
+	for i := range injected {
+		completeContainer(&injected[i], envVars)
+		s.addVolumeMounts(&injected[i], profile)
+		s.addResourcesLimits(&injected[i], profile)
+		addSecurityContext(&injected[i], psaLevel)
+	}
+	return injected, nil
+}
+
+// createContainers returns long-running NSM containers that should be injected into the pod.
+func (s *admissionWebhookServer) createContainers(profile *config.Profile, psaLevel psa.Level, data *config.TemplateData, envVars ...corev1.EnvVar) ([]corev1.Container, error) {
+	injected, err := injectedContainers(profile.ContainerImages, profile.GetContainerTemplates(), data)
+	if err != nil {
+		return nil, err
+	}
+	for i := range injected {
+		completeContainer(&injected[i], envVars)
+		s.addVolumeMounts(&injected[i], profile)
+		s.addResourcesLimits(&injected[i], profile)
+		addSecurityContext(&injected[i], psaLevel)
+	}
+	return injected, nil
+}
+
+// arrangeContainers merges NSM containers with the containers of the pod spec.
+// In the legacy layout NSM init containers are appended to the pod init containers and NSM containers to the pod containers.
+// With native sidecars NSM containers are appended to the init containers with restartPolicy: Always, so they are started
+// before the app containers and don't block Job completion. Config.NativeSidecarsBeforeInitContainers places all NSM
+// containers before the pod init containers.
+func (s *admissionWebhookServer) arrangeContainers(spec *corev1.PodSpec, nsmInitContainers, nsmContainers []corev1.Container) (initContainers, containers []corev1.Container) {
+	if !s.nativeSidecars {
+		return append(spec.InitContainers, nsmInitContainers...), append(spec.Containers, nsmContainers...)
+	}
+	restartPolicy := corev1.ContainerRestartPolicyAlways
+	for i := range nsmContainers {
+		nsmContainers[i].RestartPolicy = &restartPolicy
+	}
+	nsmInitContainers = append(nsmInitContainers, nsmContainers...)
+	if s.config.NativeSidecarsBeforeInitContainers {
+		return append(nsmInitContainers, spec.InitContainers...), spec.Containers
+	}
+	return append(spec.InitContainers, nsmInitContainers...), spec.Containers
+}
+
+// injectedContainers returns containers created from the plain images followed by the rendered container templates.
+func injectedContainers(images []string, templates *config.ContainerTemplates, data *config.TemplateData) ([]corev1.Container, error) {
+	var result []corev1.Container
+	for _, img := range images {
+		result = append(result, corev1.Container{
+			Name:  nameOf(img),
+			Image: img,
+		})
+	}
+	rendered, err := templates.Render(data)
+	if err != nil {
+		return nil, err
+	}
+	return append(result, rendered...), nil
+}
+
+// completeContainer fills the fields that are not set by the container template and merges computed envs.
+// Envs declared in the template take precedence over the computed ones with the same name.
+func completeContainer(c *corev1.Container, envVars []corev1.EnvVar) {
+	if c.Name == "" {
+		c.Name = nameOf(c.Image)
+	}
+	if c.ImagePullPolicy == "" {
+		c.ImagePullPolicy = corev1.PullIfNotPresent
+	}
+	declared := make(map[string]bool, len(c.Env))
+	for i := range c.Env {
+		declared[c.Env[i].Name] = true
+	}
+	var env []corev1.EnvVar
+	for i := range envVars {
+		if !declared[envVars[i].Name] {
+			env = append(env, envVars[i])
 		}
-		nsmURLs = append(nsmURLs, (*nsurl.NSURL)(u))
 	}
+	c.Env = append(env, c.Env...)
+}
 
-	for _, nsmURL := range nsmURLs {
-		labels := nsmURL.Labels()
-		if _, ok := labels["sriovToken"]; ok {
-			interfacePools := strings.Split(labels["sriovToken"], ",")
-			poolResources[interfacePools[0]]++
+// addSecurityContext sets fields of the SecurityContext that are required by the k8s restricted policy
+func addSecurityContext(c *corev1.Container, psaLevel psa.Level) {
+	if psaLevel != psa.LevelRestricted {
+		return
+	}
+	if c.SecurityContext == nil {
+		c.SecurityContext = new(corev1.SecurityContext)
+	}
+	if c.SecurityContext.Capabilities == nil {
+		c.SecurityContext.Capabilities = &corev1.Capabilities{
+			Drop: []corev1.Capability{"ALL"},
+		}
+	}
+	if c.SecurityContext.AllowPrivilegeEscalation == nil {
+		allowPrivilegeEscalation := false
+		c.SecurityContext.AllowPrivilegeEscalation = &allowPrivilegeEscalation
+	}
+	if c.SecurityContext.RunAsNonRoot == nil {
+		runAsNonRoot := true
+		c.SecurityContext.RunAsNonRoot = &runAsNonRoot
+	}
+	if c.SecurityContext.SeccompProfile == nil {
+		c.SecurityContext.SeccompProfile = &corev1.SeccompProfile{
+			Type: corev1.SeccompProfileTypeRuntimeDefault,
 		}
 	}
+}
 
-	return poolResources
+func envValues(envVars []corev1.EnvVar) map[string]string {
+	result := make(map[string]string, len(envVars))
+	for i := range envVars {
+		result[envVars[i].Name] = envVars[i].Value
+	}
+	return result
+}
+
+func nameOf(img string) string {
+	return strings.Split(path.Base(img), ":")[0]
 }
 
-func (s *admissionWebhookServer) createInitContainerPatch(p, v string, initContainers []corev1.Container, psaLevel psa.Level, envVars ...corev1.EnvVar) jsonpatch.JsonPatchOperation {
-	poolResources := parseResources(v, s.logger)
-	for _, img := range s.config.InitContainerImages {
-		initContainers = append(initContainers, corev1.Container{
-			Name:            nameOf(img),
-			Env:             envVars,
-			Image:           img,
-			ImagePullPolicy: corev1.PullIfNotPresent,
+// addDeviceResources attaches resources computed by the resource rules to the NSM containers.
+func addDeviceResources(initContainers, containers []corev1.Container, resources map[string]corev1.ResourceList) {
+	for target, r := range resources {
+		forEachTarget(initContainers, containers, target, func(c *corev1.Container) {
+			addResources(c, r)
 		})
-		s.addVolumeMounts(&initContainers[len(initContainers)-1])
-		s.addResources(&initContainers[len(initContainers)-1], poolResources)
-		s.addResourcesLimits(&initContainers[len(initContainers)-1])
-
-		// SecurityContext is required by the k8s restricted policy
-		if psaLevel == psa.LevelRestricted {
-			allowPrivilegeEscalation := false
-			initContainers[len(initContainers)-1].SecurityContext = &corev1.SecurityContext{
-				Capabilities: &corev1.Capabilities{
-					Drop: []corev1.Capability{"ALL"},
-				},
-				AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+	}
+}
+
+// maxResourceClaims limits the number of claims created for a pod, the quantities are controlled by the users.
+const maxResourceClaims = 32
+
+// checkResourceClaims checks that the quantities of the resources having Config.ResourceClaimTemplates are integers
+// and the number of the claims doesn't exceed maxResourceClaims.
+func (s *admissionWebhookServer) checkResourceClaims(resources map[string]corev1.ResourceList) error {
+	var total int64
+	for _, r := range resources {
+		for name, quantity := range r {
+			templateName, ok := s.config.ResourceClaimTemplates[string(name)]
+			if !ok {
+				continue
+			}
+			count, ok := quantity.AsInt64()
+			if !ok || count < 0 {
+				return errors.Errorf("quantity %s of resource %s claimed by ResourceClaimTemplate %s is not a non-negative integer", quantity.String(), name, templateName)
+			}
+			if total += count; total > maxResourceClaims {
+				return errors.Errorf("resources claimed by ResourceClaimTemplates exceed %d devices", maxResourceClaims)
 			}
 		}
 	}
-	return jsonpatch.NewOperation("add", path.Join(p, "spec", "initContainers"), initContainers)
+	return nil
 }
 
-func (s *admissionWebhookServer) createContainerPatch(p string, containers []corev1.Container, psaLevel psa.Level, envVars ...corev1.EnvVar) jsonpatch.JsonPatchOperation {
-	for _, img := range s.config.ContainerImages {
-		containers = append(containers, corev1.Container{
-			Name:            nameOf(img),
-			Env:             envVars,
-			Image:           img,
-			ImagePullPolicy: corev1.PullIfNotPresent,
-		})
-		s.addVolumeMounts(&containers[len(containers)-1])
-		s.addResourcesLimits(&containers[len(containers)-1])
-
-		// SecurityContext is required by the k8s restricted policy
-		if psaLevel == psa.LevelRestricted {
-			allowPrivilegeEscalation := false
-			containers[len(containers)-1].SecurityContext = &corev1.SecurityContext{
-				Capabilities: &corev1.Capabilities{
-					Drop: []corev1.Capability{"ALL"},
-				},
-				AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+// createResourceClaims moves the resources that have Config.ResourceClaimTemplates out of the passed resources and
+// returns pod resource claims for them. Each claim requests one device, so the integer quantity of the resource is
+// the number of claims, see checkResourceClaims. The claims are wired into the same NSM containers as the resources
+// would be. Targets and resources are sorted, so the claim names are stable.
+func (s *admissionWebhookServer) createResourceClaims(initContainers, containers []corev1.Container, resources map[string]corev1.ResourceList) []corev1.PodResourceClaim {
+	var podClaims []corev1.PodResourceClaim
+	for _, target := range slices.Sorted(maps.Keys(resources)) {
+		r := resources[target]
+		var claims []corev1.ResourceClaim
+		for _, name := range slices.Sorted(maps.Keys(r)) {
+			templateName, ok := s.config.ResourceClaimTemplates[string(name)]
+			if !ok {
+				continue
 			}
+			quantity := r[name]
+			count, _ := quantity.AsInt64()
+			for i := int64(0); i < count; i++ {
+				claimName := claimNameOf(templateName, len(podClaims))
+				podClaims = append(podClaims, corev1.PodResourceClaim{
+					Name:                      claimName,
+					ResourceClaimTemplateName: &templateName,
+				})
+				claims = append(claims, corev1.ResourceClaim{Name: claimName})
+			}
+			delete(r, name)
 		}
+		forEachTarget(initContainers, containers, target, func(c *corev1.Container) {
+			c.Resources.Claims = append(c.Resources.Claims, claims...)
+		})
 	}
-	return jsonpatch.NewOperation("add", path.Join(p, "spec", "containers"), containers)
+	return podClaims
 }
 
-func nameOf(img string) string {
-	return strings.Split(path.Base(img), ":")[0]
+// claimNameOf returns a DNS label unique inside the pod for the claim from the template.
+func claimNameOf(templateName string, index int) string {
+	suffix := fmt.Sprintf("-%d", index)
+	name := "nsm-" + strings.ReplaceAll(templateName, ".", "-")
+	if len(name)+len(suffix) > validation.DNS1123LabelMaxLength {
+		name = strings.TrimRight(name[:validation.DNS1123LabelMaxLength-len(suffix)], "-")
+	}
+	return name + suffix
 }
 
-func (s *admissionWebhookServer) addResources(c *corev1.Container, r map[string]int) {
-	for key, value := range r {
-		if c.Resources.Limits == nil {
-			c.Resources.Limits = make(map[corev1.ResourceName]resource.Quantity)
+// forEachTarget calls fn for the NSM containers selected by the resource rule target.
+// Init containers run one by one and the pod requests the maximum of their resources, so the resources are added to
+// each of them. Regular containers and native sidecars run together and their resources are summed, so the resources
+// are added only to the first NSM container. The auto target selects NSM init containers, or the first NSM container
+// if there are no NSM init containers.
+func forEachTarget(initContainers, containers []corev1.Container, target string, fn func(c *corev1.Container)) {
+	if target == config.AutoResourceTarget {
+		target = config.ContainersResourceTarget
+		if len(initContainers) > 0 {
+			target = config.InitContainersResourceTarget
 		}
-		c.Resources.Limits[corev1.ResourceName(key)] = resource.MustParse(strconv.Itoa(value))
 	}
+	if target == config.InitContainersResourceTarget || target == config.AllResourceTarget {
+		for i := range initContainers {
+			fn(&initContainers[i])
+		}
+	}
+	if (target == config.ContainersResourceTarget || target == config.AllResourceTarget) && len(containers) > 0 {
+		fn(&containers[0])
+	}
+}
+
+// addResources merges extended resources into both requests and limits, as they can't be overcommitted.
+func addResources(c *corev1.Container, r corev1.ResourceList) {
+	if c.Resources.Limits == nil {
+		c.Resources.Limits = make(corev1.ResourceList)
+	}
+	if c.Resources.Requests == nil {
+		c.Resources.Requests = make(corev1.ResourceList)
+	}
+	for name, quantity := range r {
+		c.Resources.Limits[name] = quantity.DeepCopy()
+		c.Resources.Requests[name] = quantity.DeepCopy()
+	}
+}
+
+// addResourcesLimits sets the configured CPU and memory requests and limits unless the container template defines them.
+func (s *admissionWebhookServer) addResourcesLimits(c *corev1.Container, profile *config.Profile) {
+	if c.Resources.Limits == nil {
+		c.Resources.Limits = make(corev1.ResourceList)
+	}
+	if c.Resources.Requests == nil {
+		c.Resources.Requests = make(corev1.ResourceList)
+	}
+	setDefaultQuantity(c.Resources.Limits, corev1.ResourceCPU, profile.SidecarLimitsCPU)
+	setDefaultQuantity(c.Resources.Limits, corev1.ResourceMemory, profile.SidecarLimitsMemory)
+	setDefaultQuantity(c.Resources.Requests, corev1.ResourceCPU, profile.SidecarRequestsCPU)
+	setDefaultQuantity(c.Resources.Requests, corev1.ResourceMemory, profile.SidecarRequestsMemory)
 }
 
-func (s *admissionWebhookServer) addResourcesLimits(c *corev1.Container) {
-	c.Resources = corev1.ResourceRequirements{
-		Limits: corev1.ResourceList{
-			"cpu":    resource.MustParse(s.config.SidecarLimitsCPU),
-			"memory": resource.MustParse(s.config.SidecarLimitsMemory),
-		},
-		Requests: corev1.ResourceList{
-			"cpu":    resource.MustParse(s.config.SidecarRequestsCPU),
-			"memory": resource.MustParse(s.config.SidecarRequestsMemory),
-		},
+// setDefaultQuantity sets the quantity if it's not set yet. The quantities of profiles are validated at startup by Config.Validate.
+func setDefaultQuantity(list corev1.ResourceList, name corev1.ResourceName, value string) {
+	if _, ok := list[name]; !ok {
+		list[name] = resource.MustParse(value)
 	}
 }
 
-func (s *admissionWebhookServer) addVolumeMounts(c *corev1.Container) {
+func (s *admissionWebhookServer) addVolumeMounts(c *corev1.Container, profile *config.Profile) {
 	c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{
 		Name:      "spire-agent-socket",
-		MountPath: "/run/spire/sockets",
+		MountPath: s.config.SpireSocketMountPath,
 		ReadOnly:  true,
 	}, corev1.VolumeMount{
 		Name:      "nsm-socket",
-		MountPath: "/var/lib/networkservicemesh",
+		MountPath: s.config.NSMSocketMountPath,
 		ReadOnly:  true,
 	})
+	c.VolumeMounts = append(c.VolumeMounts, profile.VolumeMounts...)
+}
+
+// setStatusAnnotation sets Config.StatusAnnotation with the passed status to the resource metadata.
+func (s *admissionWebhookServer) setStatusAnnotation(metaPtr *v1.ObjectMeta, status string) {
+	if metaPtr.Annotations == nil {
+		metaPtr.Annotations = make(map[string]string)
+	}
+	metaPtr.Annotations[s.config.StatusAnnotation] = status
 }
 
-func (s *admissionWebhookServer) createLabelPatch(p string, v map[string]string) jsonpatch.JsonPatchOperation {
-	for key, value := range s.config.Labels {
-		v[key] = value
+func (s *admissionWebhookServer) addLabels(podMetaPtr *v1.ObjectMeta, profile *config.Profile) {
+	for key, value := range profile.Labels {
+		podMetaPtr.Labels[key] = value
 	}
-	return jsonpatch.NewOperation("add", path.Join(p, "metadata", "labels"), v)
 }
 
 func main() {
@@ -406,6 +1114,10 @@ func main() {
 
 	var conf = new(config.Config)
 
+	if len(os.Args) > 1 && os.Args[1] == validateConfigCommand {
+		os.Exit(validateConfig(conf))
+	}
+
 	if err = envconfig.Usage("nsm", conf); err != nil {
 		prod.Fatal(err.Error())
 	}
@@ -414,10 +1126,18 @@ func main() {
 		prod.Fatal(err.Error())
 	}
 
+	if err = parseFlags(conf, os.Args[1:]); err != nil {
+		prod.Fatal(err.Error())
+	}
+
 	var logger = prod.Sugar()
 
 	logger.Infof("config.Config: %#v", conf)
 
+	if err = conf.Validate(); err != nil {
+		logger.Fatal(err.Error())
+	}
+
 	ctx, cancel := signal.NotifyContext(context.Background(),
 		os.Interrupt,
 		os.Kill,
@@ -444,14 +1164,18 @@ func main() {
 		go pprofutils.ListenAndServe(ctx, conf.PprofListenOn)
 	}
 
+	clientset, err := k8s.NewClientset(conf)
+	if err != nil {
+		logger.Fatal(err.Error())
+	}
+
+	var registerClient *k8s.AdmissionWebhookRegisterClient
 	if conf.WebhookMode == config.SelfregisterMode {
-		unregister := registerSelf(ctx, conf, logger)
-		defer func() {
-			_ = unregister(context.Background(), conf)
-		}()
+		registerClient = registerSelf(ctx, conf, clientset, logger)
 	}
+	reconciler := startReconciler(ctx, conf, registerClient, clientset, logger)
 
-	tlsConfig, err := prepareTLSConfig(ctx, conf, logger)
+	tlsConfig, x509Source, err := prepareTLSConfig(ctx, conf)
 	if err != nil {
 		logger.Fatal(err.Error())
 	}
@@ -459,105 +1183,234 @@ func main() {
 	s := echo.New()
 	s.Use(middleware.Logger())
 	s.Use(middleware.Recover())
-	restConfig, err := kubeutils.NewClientSetConfig(
-		kubeutils.WithQPS(float32(conf.KubeletQPS)),
-		kubeutils.WithBurst(conf.KubeletQPS*2),
-	)
+	podSecurity, err := podsecurity.NewEvaluator()
 	if err != nil {
 		logger.Fatal(err.Error())
 	}
-	clientset, err := kubernetes.NewForConfig(restConfig)
+	podSecurityDefaults, err := podsecurity.ParseDefaults(conf.PodSecurityDefaults)
 	if err != nil {
 		logger.Fatal(err.Error())
 	}
 	var handler = &admissionWebhookServer{
-		config:    conf,
-		logger:    logger.Named("admissionWebhookServer"),
-		clientset: clientset,
+		config:              conf,
+		logger:              logger.Named("admissionWebhookServer"),
+		clientset:           clientset,
+		podSecurity:         podSecurity,
+		podSecurityDefaults: podSecurityDefaults,
+		nativeSidecars:      isNativeSidecarsEnabled(conf, clientset, logger),
 	}
 
-	s.POST("/mutate", func(c echo.Context) error {
-		msg, err := io.ReadAll(c.Request().Body)
-		if err != nil {
-			return err
+	s.POST("/mutate", handler.mutate())
+	liveness, readiness := newHealthCheckers(conf, tlsConfig, clientset, registerClient, reconciler)
+	var shuttingDown atomic.Bool
+	readiness.Add("shutdown", func(context.Context) error {
+		if shuttingDown.Load() {
+			return errors.New("the webhook is shutting down")
 		}
-		var review = new(admissionv1.AdmissionReview)
-
-		_, _, err = deserializer.Decode(msg, nil, review)
-		if err != nil {
-			return err
-		}
-
-		review.Response = handler.Review(ctx, review.Request)
-		response, err := json.Marshal(review)
-		if err != nil {
-			return err
-		}
-		_, err = c.Response().Write(response)
-		return err
-	})
-	s.GET("/ready", func(c echo.Context) error {
-		return c.NoContent(http.StatusOK)
+		return nil
 	})
+	servers := serveHealthChecks(conf, s, liveness, readiness)
+	ops := servers[len(servers)-1]
 
-	var startServerErr = make(chan error)
+	var startServerErr = make(chan error, len(servers))
 
 	go func() {
 		// #nosec
 		var server = &http.Server{
-			Addr:      ":443",
+			Addr:      conf.ListenOn,
 			TLSConfig: tlsConfig,
 		}
 		startServerErr <- s.StartServer(server)
 	}()
+	if ops != s {
+		go func() {
+			startServerErr <- ops.Start(conf.OperationalListenOn)
+		}()
+	}
 
+	var serverErr error
 	select {
-	case err := <-startServerErr:
-		if ctx.Err() != nil {
-			logger.Fatal(err.Error())
-		}
+	case serverErr = <-startServerErr:
+		logger.Errorf("admission webhook server stopped: %v", serverErr.Error())
 	case <-ctx.Done():
+	}
+	shutdown(conf, logger, servers, &shuttingDown, x509Source, registerClient, reconciler)
+	if serverErr != nil {
+		os.Exit(1)
+	}
+}
+
+// serveHealthChecks adds the health check endpoints to the separate operational server if Config.OperationalListenOn
+// is specified, otherwise to the admission server. Returns the admission server followed by the operational one.
+func serveHealthChecks(conf *config.Config, s *echo.Echo, liveness, readiness *health.Checker) []*echo.Echo {
+	servers := []*echo.Echo{s}
+	ops := s
+	if conf.OperationalListenOn != "" {
+		ops = echo.New()
+		ops.Use(middleware.Recover())
+		servers = append(servers, ops)
+	}
+	ops.GET("/healthz", liveness.Handler())
+	ops.GET("/readyz", readiness.Handler())
+	// /ready is kept for deployments created before /readyz
+	ops.GET("/ready", readiness.Handler())
+	return servers
+}
+
+// shutdown stops the webhook in order: the replica is marked not ready and waits for the grace period, so it's removed
+// from the service endpoints, then in-flight admissions are drained, the SPIRE source and the reconciliation of the webhook
+// configuration are stopped and finally the webhook configuration is deregistered if it's still owned by this replica.
+func shutdown(conf *config.Config, logger *zap.SugaredLogger, servers []*echo.Echo, shuttingDown *atomic.Bool, x509Source io.Closer,
+	registerClient *k8s.AdmissionWebhookRegisterClient, reconciler *k8s.WebhookConfigurationReconciler) {
+	shuttingDown.Store(true)
+	logger.Infof("Shutting down, waiting %s for the endpoints update", conf.ShutdownGracePeriod)
+	time.Sleep(conf.ShutdownGracePeriod)
+
+	ctx, cancel := context.WithTimeout(context.Background(), conf.ShutdownTimeout)
+	defer cancel()
+	// the admission server is the first one, so the operational endpoints are served while admissions are drained
+	for _, s := range servers {
+		if err := s.Shutdown(ctx); err != nil {
+			logger.Errorf("failed to drain requests: %v", err.Error())
+		}
+	}
+	if x509Source != nil {
+		if err := x509Source.Close(); err != nil {
+			logger.Errorf("unable to close x509 source: %v", err.Error())
+		}
+	}
+	if reconciler != nil {
+		reconciler.Stop()
+	}
+	if registerClient == nil {
 		return
 	}
+	owned, err := registerClient.UnregisterOwned(ctx, conf)
+	if err != nil {
+		logger.Errorf("failed to deregister MutatingWebhookConfiguration %s: %v", conf.Name, err.Error())
+		return
+	}
+	if !owned {
+		logger.Infof("MutatingWebhookConfiguration %s is registered by another replica, skipping deregistration", conf.Name)
+	}
 }
 
-// prepareTLSConfig returns a configuration that includes certificates for proper working of http.Server, depending on the selected webhook mode.
-func prepareTLSConfig(ctx context.Context, c *config.Config, logger *zap.SugaredLogger) (*tls.Config, error) {
-	tlsConfig := &tls.Config{
-		MinVersion: tls.VersionTLS12,
+// parseFlags overrides the configuration from NSM_* envs by the command line flags.
+func parseFlags(conf *config.Config, args []string) error {
+	flags := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	flags.StringVar(&conf.Kubeconfig, "kubeconfig", conf.Kubeconfig, "path to kubeconfig, overrides NSM_KUBECONFIG")
+	flags.StringVar(&conf.KubeContext, "context", conf.KubeContext, "context of the kubeconfig, overrides NSM_KUBE_CONTEXT")
+	return flags.Parse(args)
+}
+
+// validateConfigCommand validates the configuration from NSM_* envs and exits, e.g. to check deployment manifests in CI.
+const validateConfigCommand = "validate-config"
+
+// validateConfig reports all problems of the configuration and returns the exit code.
+func validateConfig(conf *config.Config) int {
+	if err := envconfig.Process("nsm", conf); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	if err := conf.Validate(); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	_, _ = fmt.Println("configuration is valid")
+	return 0
+}
+
+// prepareTLSConfig returns the TLS config of the admission server. The returned SPIRE source, if any, must be closed on shutdown.
+func prepareTLSConfig(ctx context.Context, c *config.Config) (*tls.Config, io.Closer, error) {
+	tlsConfig, err := c.NewTLSConfig()
+	if err != nil {
+		return nil, nil, err
 	}
 
 	if c.WebhookMode == config.SpireMode && !c.IsExistingCertificatesUsed() {
 		source, err := workloadapi.NewX509Source(ctx)
 		if err != nil {
-			return nil, errors.Errorf("error getting x509 source: %v", err.Error())
+			return nil, nil, errors.Errorf("error getting x509 source: %v", err.Error())
 		}
 		tlsConfig.GetCertificate = tlsconfig.GetCertificate(source)
+		return tlsConfig, source, nil
+	}
 
-		select {
-		case <-ctx.Done():
-			err = source.Close()
-			logger.Errorf("unable to close x509 source: %v", err.Error())
-		default:
-		}
-	} else {
-		tlsConfig.Certificates = []tls.Certificate{c.GetOrResolveCertificate()}
+	tlsConfig.Certificates = []tls.Certificate{c.GetOrResolveCertificate()}
+	return tlsConfig, nil, nil
+}
+
+// newHealthCheckers creates the liveness checks of the process and the readiness checks of the dependencies needed to serve admissions.
+func newHealthCheckers(conf *config.Config, tlsConfig *tls.Config, clientset kubernetes.Interface,
+	registerClient *k8s.AdmissionWebhookRegisterClient, reconciler *k8s.WebhookConfigurationReconciler) (liveness, readiness *health.Checker) {
+	liveness = health.NewChecker(conf.HealthCheckTimeout)
+	liveness.Add("certificate", health.CertificateCheck(tlsConfig, false))
+	readiness = health.NewChecker(conf.HealthCheckTimeout)
+	readiness.Add("certificate", health.CertificateCheck(tlsConfig, true))
+	readiness.Add("api-server", func(ctx context.Context) error {
+		_, err := clientset.CoreV1().Namespaces().Get(ctx, conf.Namespace, v1.GetOptions{})
+		return errors.Wrap(err, "API server is not reachable")
+	})
+	if registerClient != nil {
+		readiness.Add("registration", func(ctx context.Context) error {
+			return registerClient.CheckRegistered(ctx, conf)
+		})
+	}
+	if reconciler != nil {
+		readiness.Add("caches", reconciler.CheckSynced)
 	}
+	return liveness, readiness
+}
 
-	return tlsConfig, nil
+// isNativeSidecarsEnabled checks whether NSM containers should be injected as native sidecars.
+// Falls back to the legacy layout if the API server doesn't support them or its version can't be discovered.
+func isNativeSidecarsEnabled(conf *config.Config, clientset kubernetes.Interface, logger *zap.SugaredLogger) bool {
+	if !conf.NativeSidecars {
+		return false
+	}
+	supported, err := k8s.IsNativeSidecarsSupported(clientset.Discovery())
+	if err != nil {
+		logger.Errorf("unable to check native sidecars support, falling back to the legacy layout: %v", err.Error())
+		return false
+	}
+	if !supported {
+		logger.Warnf("API server doesn't support native sidecars, falling back to the legacy layout")
+	}
+	return supported
 }
 
-func registerSelf(ctx context.Context, conf *config.Config, logger *zap.SugaredLogger) func(ctx context.Context, c *config.Config) error {
-	var registerClient = k8s.AdmissionWebhookRegisterClient{
-		Logger: logger.Named("admissionWebhookRegisterClient"),
+// startReconciler starts restoring the webhook configuration registered in selfregister mode.
+// Returns nil if the webhook configuration isn't registered or the reconciliation is disabled.
+func startReconciler(ctx context.Context, conf *config.Config, registerClient *k8s.AdmissionWebhookRegisterClient, clientset kubernetes.Interface,
+	logger *zap.SugaredLogger) *k8s.WebhookConfigurationReconciler {
+	if !conf.IsWebhookReconciliationEnabled() {
+		if conf.WebhookMode != config.SelfregisterMode {
+			logger.Infof("reconciliation of MutatingWebhookConfiguration is disabled in %s mode", conf.WebhookMode)
+		} else {
+			logger.Infof("reconciliation of MutatingWebhookConfiguration %s is disabled by NSM_WEBHOOK_RECONCILE_PERIOD=0", conf.Name)
+		}
+		return nil
+	}
+	if !conf.IsExistingCertificatesUsed() {
+		logger.Infof("only MutatingWebhookConfiguration %s registered by this replica is reconciled, the certificate isn't shared", conf.Name)
 	}
+	reconciler, err := k8s.NewWebhookConfigurationReconciler(logger.Named("webhookConfigurationReconciler"), conf, clientset, registerClient.Owner())
+	if err != nil {
+		logger.Fatal(err.Error())
+	}
+	reconciler.Start(ctx)
+	return reconciler
+}
+
+func registerSelf(ctx context.Context, conf *config.Config, clientset kubernetes.Interface, logger *zap.SugaredLogger) *k8s.AdmissionWebhookRegisterClient {
+	var registerClient = k8s.NewAdmissionWebhookRegisterClient(logger.Named("admissionWebhookRegisterClient"), clientset)
 
 	err := registerClient.Register(ctx, conf)
 	if err != nil {
 		logger.Fatal(err.Error())
 	}
 
-	return registerClient.Unregister
+	return registerClient
 }
 
 // Logs the response to the review request. Since the patch part of
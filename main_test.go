@@ -0,0 +1,1062 @@
+// Copyright (c) 2026 Nordix Foundation.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	psa "k8s.io/pod-security-admission/api"
+
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/config/configtest"
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/health"
+	"github.com/networkservicemesh/cmd-admission-webhook/internal/podsecurity"
+)
+
+const testNamespace = "ns"
+
+func newTestServer(t *testing.T, namespaceAnnotations map[string]string) *admissionWebhookServer {
+	conf := configtest.New(t)
+	evaluator, err := podsecurity.NewEvaluator()
+	require.NoError(t, err)
+	podSecurityDefaults, err := podsecurity.ParseDefaults(conf.PodSecurityDefaults)
+	require.NoError(t, err)
+	return &admissionWebhookServer{
+		config: conf,
+		logger: zap.NewNop().Sugar(),
+		clientset: fake.NewSimpleClientset(&corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: testNamespace, Annotations: namespaceAnnotations},
+		}),
+		podSecurity:         evaluator,
+		podSecurityDefaults: podSecurityDefaults,
+	}
+}
+
+// setNamespaceLabels replaces the namespace of the server with the namespace having the labels, e.g. PSA labels.
+func setNamespaceLabels(s *admissionWebhookServer, labels map[string]string) {
+	s.clientset = fake.NewSimpleClientset(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: testNamespace, Labels: labels},
+	})
+}
+
+func review(t *testing.T, s *admissionWebhookServer, object runtime.Object) *admissionv1.AdmissionResponse {
+	raw, err := json.Marshal(object)
+	require.NoError(t, err)
+	kind := object.GetObjectKind().GroupVersionKind()
+	return s.Review(context.Background(), &admissionv1.AdmissionRequest{
+		UID:       "uid",
+		Operation: admissionv1.Create,
+		Namespace: testNamespace,
+		Kind:      metav1.GroupVersionKind{Group: kind.Group, Version: kind.Version, Kind: kind.Kind},
+		Object:    runtime.RawExtension{Raw: raw},
+	})
+}
+
+func newDeployment(annotations, templateAnnotations map[string]string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: testNamespace, Annotations: annotations},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Annotations: templateAnnotations},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "alpine"}}},
+			},
+		},
+	}
+}
+
+func TestReview_Skip(t *testing.T) {
+	ownedReplicaSet := &appsv1.ReplicaSet{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "ReplicaSet"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "web-1",
+			Namespace:       testNamespace,
+			OwnerReferences: []metav1.OwnerReference{{Kind: "Deployment", Name: "web"}},
+		},
+		Spec: appsv1.ReplicaSetSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"networkservicemesh.io/injection-status": "injected"}},
+			},
+		},
+	}
+	for _, tc := range []struct {
+		name     string
+		object   runtime.Object
+		warnings int
+		status   string
+	}{
+		{
+			name:   "not annotated workload with pod template annotations",
+			object: newDeployment(nil, map[string]string{"prometheus.io/scrape": "true"}),
+		},
+		{
+			name:   "ReplicaSet of injected Deployment",
+			object: ownedReplicaSet,
+		},
+		{
+			name:   "not annotated pod",
+			object: &corev1.Pod{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"}},
+		},
+		{
+			name: "annotated workload with pod template annotations",
+			object: newDeployment(map[string]string{"networkservicemesh.io": "kernel://ns/nsm-1"},
+				map[string]string{"prometheus.io/scrape": "true"}),
+			warnings: 1,
+			status:   "skipped:malformed-specification",
+		},
+		{
+			name: "workload with annotated pod template",
+			object: newDeployment(map[string]string{"networkservicemesh.io": "kernel://ns/nsm-1"},
+				map[string]string{"networkservicemesh.io": "kernel://ns/nsm-2"}),
+			warnings: 1,
+			status:   "deferred",
+		},
+		{
+			name:     "workload selecting profile without network services",
+			object:   newDeployment(map[string]string{"networkservicemesh.io/profile": "default"}, nil),
+			warnings: 1,
+			status:   "skipped:no-annotation",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := review(t, newTestServer(t, nil), tc.object)
+			require.True(t, resp.Allowed)
+			require.Nil(t, resp.Result)
+			require.Len(t, resp.Warnings, tc.warnings)
+			if tc.status == "" {
+				require.Nil(t, resp.Patch)
+				return
+			}
+			require.Contains(t, string(resp.Patch), `"`+tc.status+`"`)
+		})
+	}
+}
+
+func newPod(annotations map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{Name: "p", Namespace: testNamespace, Annotations: annotations},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "alpine"}}},
+	}
+}
+
+// mutatedPod applies the patch of the response to the pod.
+func mutatedPod(t *testing.T, pod *corev1.Pod, resp *admissionv1.AdmissionResponse) *corev1.Pod {
+	require.True(t, resp.Allowed, resp.Result)
+	original, err := json.Marshal(pod)
+	require.NoError(t, err)
+	patch, err := jsonpatch.DecodePatch(resp.Patch)
+	require.NoError(t, err)
+	raw, err := patch.Apply(original)
+	require.NoError(t, err)
+	mutated := new(corev1.Pod)
+	require.NoError(t, json.Unmarshal(raw, mutated))
+	return mutated
+}
+
+func TestReview_Resources(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		envs       map[string]string
+		annotation string
+		// resources are the expected requests and limits of the NSM containers except CPU and memory
+		resources corev1.ResourceList
+		// nsmContainers are the names of the containers having the resources
+		nsmContainers []string
+	}{
+		{
+			name:          "one pool",
+			annotation:    "kernel://ns/nsm-1?sriovToken=intel/10G",
+			resources:     corev1.ResourceList{"intel/10G": resource.MustParse("1")},
+			nsmContainers: []string{"cmd-nsc"},
+		},
+		{
+			name:       "several pools of one URL",
+			annotation: "kernel://ns/nsm-1?sriovToken=intel/10G&sriovToken=intel/25G",
+			resources: corev1.ResourceList{
+				"intel/10G": resource.MustParse("1"),
+				"intel/25G": resource.MustParse("1"),
+			},
+			nsmContainers: []string{"cmd-nsc"},
+		},
+		{
+			name:          "pool of several URLs",
+			annotation:    "kernel://ns/nsm-1?sriovToken=intel/10G,kernel://ns/nsm-2?sriovToken=intel/10G",
+			resources:     corev1.ResourceList{"intel/10G": resource.MustParse("2")},
+			nsmContainers: []string{"cmd-nsc"},
+		},
+		{
+			name:          "comma-separated pools of structured annotation",
+			annotation:    `[{"networkService": "ns", "labels": {"sriovToken": "intel/10G,intel/25G"}, "resources": {"hugepages-2Mi": "512Mi"}}]`,
+			resources:     corev1.ResourceList{"intel/10G": resource.MustParse("1"), "intel/25G": resource.MustParse("1"), "hugepages-2Mi": resource.MustParse("512Mi")},
+			nsmContainers: []string{"cmd-nsc"},
+		},
+		{
+			name:          "init containers",
+			envs:          map[string]string{"NSM_INIT_CONTAINER_IMAGES": "ghcr.io/networkservicemesh/cmd-nsc-init:latest,ghcr.io/networkservicemesh/cmd-init:latest"},
+			annotation:    "kernel://ns/nsm-1?sriovToken=intel/10G",
+			resources:     corev1.ResourceList{"intel/10G": resource.MustParse("1")},
+			nsmContainers: []string{"cmd-nsc-init", "cmd-init"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for name, value := range tc.envs {
+				t.Setenv(name, value)
+			}
+			s := newTestServer(t, nil)
+			pod := newPod(map[string]string{"networkservicemesh.io": tc.annotation})
+			mutated := mutatedPod(t, pod, review(t, s, pod))
+
+			withResources := make(map[string]bool)
+			for _, c := range append(mutated.Spec.InitContainers, mutated.Spec.Containers[1:]...) {
+				// the default CPU and memory are set along with the extended resources
+				require.Contains(t, c.Resources.Limits, corev1.ResourceCPU, c.Name)
+				require.Contains(t, c.Resources.Requests, corev1.ResourceMemory, c.Name)
+				for name, quantity := range tc.resources {
+					limit, ok := c.Resources.Limits[name]
+					if !ok {
+						continue
+					}
+					withResources[c.Name] = true
+					require.Zero(t, quantity.Cmp(limit), "%s limits %s", c.Name, name)
+					request := c.Resources.Requests[name]
+					require.Zero(t, quantity.Cmp(request), "%s requests %s", c.Name, name)
+				}
+				if withResources[c.Name] {
+					require.Len(t, c.Resources.Limits, len(tc.resources)+2, c.Name)
+				} else {
+					require.Len(t, c.Resources.Limits, 2, c.Name)
+				}
+			}
+			require.Len(t, withResources, len(tc.nsmContainers))
+			for _, name := range tc.nsmContainers {
+				require.True(t, withResources[name], name)
+			}
+		})
+	}
+}
+
+func TestReview_ResourceClaims(t *testing.T) {
+	t.Setenv("NSM_RESOURCE_CLAIM_TEMPLATES", "intel/10G:sriov-10g")
+	s := newTestServer(t, nil)
+	resp := review(t, s, &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{Name: "p", Namespace: testNamespace, Annotations: map[string]string{
+			"networkservicemesh.io": "kernel://ns/nsm-1?sriovToken=intel/10G",
+		}},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "alpine"}}},
+	})
+	require.True(t, resp.Allowed)
+	require.Contains(t, string(resp.Patch), `"resourceClaimTemplateName":"sriov-10g"`)
+	require.NotContains(t, string(resp.Patch), `"source"`)
+}
+
+func TestReview_ResourceClaims_Stable(t *testing.T) {
+	t.Setenv("NSM_RESOURCE_CLAIM_TEMPLATES", "intel/10G:sriov-10g,intel/25G:sriov-25g,mellanox/100G:mlx-100g")
+	s := newTestServer(t, nil)
+	pod := newPod(map[string]string{
+		"networkservicemesh.io": "kernel://ns/nsm-1?sriovToken=mellanox/100G&sriovToken=intel/25G&sriovToken=intel/10G",
+	})
+	resp := review(t, s, pod)
+	claims := mutatedPod(t, pod, resp).Spec.ResourceClaims
+	require.Len(t, claims, 3)
+	require.Equal(t, "nsm-sriov-10g-0", claims[0].Name)
+	require.Equal(t, "nsm-sriov-25g-1", claims[1].Name)
+	require.Equal(t, "nsm-mlx-100g-2", claims[2].Name)
+	for i := 0; i < 20; i++ {
+		require.Equal(t, claims, mutatedPod(t, pod, review(t, s, pod)).Spec.ResourceClaims)
+	}
+}
+
+func TestReview_ResourceClaims_Invalid(t *testing.T) {
+	t.Setenv("NSM_RESOURCE_CLAIM_TEMPLATES", "intel/10G:sriov-10g")
+	s := newTestServer(t, nil)
+	for name, quantity := range map[string]string{
+		"too many claims": "200000",
+		"not an integer":  "1500m",
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp := review(t, s, newPod(map[string]string{
+				"networkservicemesh.io": `[{"networkService": "ns", "resources": {"intel/10G": "` + quantity + `"}}]`,
+			}))
+			require.False(t, resp.Allowed)
+			require.EqualValues(t, http.StatusBadRequest, resp.Result.Code)
+			require.Nil(t, resp.Patch)
+		})
+	}
+}
+
+func TestReview_Inject(t *testing.T) {
+	pod := &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{Name: "p", Namespace: testNamespace, Annotations: map[string]string{
+			"networkservicemesh.io": "kernel://ns-1/nsm-1",
+		}},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "alpine"}}},
+	}
+	resp := review(t, newTestServer(t, nil), pod)
+	require.True(t, resp.Allowed)
+	require.Nil(t, resp.Result)
+	patch := string(resp.Patch)
+	require.Contains(t, patch, `"image":"ghcr.io/networkservicemesh/cmd-nsc:latest"`)
+	require.Contains(t, patch, `{"name":"NSM_NETWORK_SERVICES","value":"kernel://ns-1/nsm-1"}`)
+	require.Contains(t, patch, `"path":"/metadata/annotations/networkservicemesh.io~1injection-status","value":"injected"`)
+
+	deployment := newDeployment(map[string]string{"networkservicemesh.io": "kernel://ns-1/nsm-1"}, nil)
+	resp = review(t, newTestServer(t, nil), deployment)
+	require.True(t, resp.Allowed)
+	require.Nil(t, resp.Result)
+	require.Contains(t, string(resp.Patch), `"path":"/spec/template/metadata/annotations","value":{"networkservicemesh.io/injection-status":"injected"}`)
+}
+
+func TestReview_InjectedPod(t *testing.T) {
+	resp := review(t, newTestServer(t, nil), &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{Name: "p", Namespace: testNamespace, Annotations: map[string]string{
+			"networkservicemesh.io/injection-status": "injected",
+		}},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "alpine"}}},
+	})
+	require.True(t, resp.Allowed)
+	require.Empty(t, resp.Warnings)
+	require.Nil(t, resp.Patch)
+}
+
+func TestReview_InvalidAnnotation(t *testing.T) {
+	resp := review(t, newTestServer(t, nil), &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{Name: "p", Namespace: testNamespace, Annotations: map[string]string{
+			"networkservicemesh.io": `[{"networkService":"ns/1"}]`,
+		}},
+	})
+	require.False(t, resp.Allowed)
+	require.EqualValues(t, 400, resp.Result.Code)
+}
+
+func TestReview_StablePatch(t *testing.T) {
+	t.Setenv("NSM_LABELS", "nsm-client:true,nsm-mode:kernel")
+	t.Setenv("NSM_RESOURCE_CLAIM_TEMPLATES", "intel/25G:sriov-25g")
+	annotations := map[string]string{
+		"networkservicemesh.io":  "kernel://ns-1/nsm-1?sriovToken=intel/10G&sriovToken=intel/25G,kernel://ns-2/nsm-2?app={{label:app}}",
+		"app.kubernetes.io/name": "web",
+	}
+	pod := newPod(annotations)
+	pod.Labels = map[string]string{"app": "web", "tier": "frontend"}
+	deployment := newDeployment(annotations, nil)
+	deployment.Spec.Template.Labels = pod.Labels
+	for _, strategy := range []string{"hash", "pod-name", "template"} {
+		t.Run(strategy, func(t *testing.T) {
+			t.Setenv("NSM_NAME_STRATEGY", strategy)
+			t.Setenv("NSM_NAME_TEMPLATE", "$(POD_NAME)-{{ .Namespace }}")
+			s := newTestServer(t, nil)
+			for _, object := range []runtime.Object{pod, deployment} {
+				expected := review(t, s, object)
+				require.True(t, expected.Allowed, expected.Result)
+				for i := 0; i < 50; i++ {
+					require.Equal(t, string(expected.Patch), string(review(t, s, object).Patch))
+				}
+			}
+		})
+	}
+}
+
+func TestReview_IndexAcrossAnnotations(t *testing.T) {
+	t.Setenv("NSM_ANNOTATION_MERGE_STRATEGY", "union")
+	s := newTestServer(t, map[string]string{"networkservicemesh.io": "kernel://{{namespace}}/nsm-{{index}}"})
+	resp := review(t, s, &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{Name: "p", Namespace: testNamespace, Annotations: map[string]string{
+			"networkservicemesh.io": "kernel://a/nsm-{{index}},kernel://b/nsm-{{index}}",
+		}},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "alpine"}}},
+	})
+	require.True(t, resp.Allowed)
+	require.Contains(t, string(resp.Patch), `{"name":"NSM_NETWORK_SERVICES","value":"kernel://ns/nsm-1,kernel://a/nsm-2,kernel://b/nsm-3"}`)
+}
+
+func post(t *testing.T, s *admissionWebhookServer, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/mutate", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	require.NoError(t, s.mutate()(echo.New().NewContext(req, rec)))
+	return rec
+}
+
+func TestMutate_UnsupportedKind(t *testing.T) {
+	s := newTestServer(t, nil)
+	for name, body := range map[string]string{
+		"kind":    `{"apiVersion":"admission.k8s.io/v1","kind":"Pod","request":{"uid":"uid"}}`,
+		"version": `{"apiVersion":"admission.k8s.io/v2","kind":"AdmissionReview","request":{"uid":"uid"}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := post(t, s, body)
+			require.Equal(t, http.StatusOK, rec.Code)
+			out := new(admissionv1.AdmissionReview)
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), out))
+			require.False(t, out.Response.Allowed)
+			require.Equal(t, "uid", string(out.Response.UID))
+			require.Equal(t, int32(http.StatusBadRequest), out.Response.Result.Code)
+			require.Contains(t, out.Response.Result.Message, "unsupported AdmissionReview kind")
+		})
+	}
+}
+
+func TestMutate_Malformed(t *testing.T) {
+	s := newTestServer(t, nil)
+	for _, tc := range []struct {
+		name, body string
+		code       int
+		status     int32
+	}{
+		{name: "no request", body: `{"apiVersion":"admission.k8s.io/v1","kind":"AdmissionReview"}`, code: http.StatusOK, status: http.StatusBadRequest},
+		{name: "undecodable", body: `{"apiVersion":`, code: http.StatusBadRequest, status: http.StatusBadRequest},
+		{name: "no kind", body: `{"request":{"uid":"uid"}}`, code: http.StatusOK, status: http.StatusBadRequest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := post(t, s, tc.body)
+			require.Equal(t, tc.code, rec.Code)
+			out := new(admissionv1.AdmissionReview)
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), out))
+			require.False(t, out.Response.Allowed)
+			require.Equal(t, tc.status, out.Response.Result.Code)
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/mutate", strings.NewReader("{}"))
+	req.Header.Set(echo.HeaderContentType, echo.MIMETextPlain)
+	rec := httptest.NewRecorder()
+	require.NoError(t, s.mutate()(echo.New().NewContext(req, rec)))
+	require.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+}
+
+func TestMutate(t *testing.T) {
+	s := newTestServer(t, nil)
+	rec := post(t, s, `{"apiVersion":"admission.k8s.io/v1beta1","kind":"AdmissionReview","request":{"uid":"uid","operation":"DELETE"}}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	out := new(admissionv1.AdmissionReview)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), out))
+	require.Equal(t, "admission.k8s.io/v1beta1", out.APIVersion)
+	require.Equal(t, "uid", string(out.Response.UID))
+	require.True(t, out.Response.Allowed)
+}
+
+func TestReview_Profiles(t *testing.T) {
+	profilesFilePath := filepath.Join(t.TempDir(), "profiles.yaml")
+	require.NoError(t, os.WriteFile(profilesFilePath, []byte(`
+- name: vpp
+  containerImages: [ghcr.io/networkservicemesh/cmd-nsc-vpp:latest]
+  labels: {nsm-flavour: vpp}
+  envs: [NSM_LOG_LEVEL=DEBUG]
+  volumes: [{name: hugepages, emptyDir: {medium: HugePages}}]
+  volumeMounts: [{name: hugepages, mountPath: /hugepages}]
+  sidecarLimitsCPU: "1"
+`), 0o600))
+	t.Setenv("NSM_PROFILES_FILE_PATH", profilesFilePath)
+	for _, tc := range []struct {
+		name                 string
+		namespaceAnnotations map[string]string
+		annotations          map[string]string
+		// vpp is true if the vpp profile is expected, otherwise the default one
+		vpp bool
+	}{
+		{
+			name: "default profile",
+		},
+		{
+			name:        "profile of workload",
+			annotations: map[string]string{"networkservicemesh.io/profile": "vpp"},
+			vpp:         true,
+		},
+		{
+			name:                 "profile of namespace",
+			namespaceAnnotations: map[string]string{"networkservicemesh.io/profile": "vpp"},
+			vpp:                  true,
+		},
+		{
+			name:                 "workload overriding profile of namespace",
+			namespaceAnnotations: map[string]string{"networkservicemesh.io/profile": "vpp"},
+			annotations:          map[string]string{"networkservicemesh.io/profile": "default"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			annotations := map[string]string{"networkservicemesh.io": "kernel://ns-1/nsm-1"}
+			for key, value := range tc.annotations {
+				annotations[key] = value
+			}
+			pod := newPod(annotations)
+			mutated := mutatedPod(t, pod, review(t, newTestServer(t, tc.namespaceAnnotations), pod))
+			require.Len(t, mutated.Spec.Containers, 2)
+			nsc := mutated.Spec.Containers[1]
+			// the profile inherits the resources it doesn't specify from the default profile
+			require.Equal(t, "80Mi", nsc.Resources.Limits.Memory().String())
+			if !tc.vpp {
+				require.Equal(t, configtest.ContainerImage, nsc.Image)
+				require.Equal(t, "200m", nsc.Resources.Limits.Cpu().String())
+				require.NotContains(t, mutated.Labels, "nsm-flavour")
+				require.Len(t, mutated.Spec.Volumes, 2)
+				return
+			}
+			require.Equal(t, "ghcr.io/networkservicemesh/cmd-nsc-vpp:latest", nsc.Image)
+			require.Equal(t, "1", nsc.Resources.Limits.Cpu().String())
+			require.Equal(t, "vpp", mutated.Labels["nsm-flavour"])
+			require.Contains(t, nsc.Env, corev1.EnvVar{Name: "NSM_LOG_LEVEL", Value: "DEBUG"})
+			require.Contains(t, nsc.VolumeMounts, corev1.VolumeMount{Name: "hugepages", MountPath: "/hugepages"})
+			require.Len(t, mutated.Spec.Volumes, 3)
+			require.Equal(t, "hugepages", mutated.Spec.Volumes[2].Name)
+		})
+	}
+}
+
+func TestReview_UnknownProfile(t *testing.T) {
+	resp := review(t, newTestServer(t, nil), newPod(map[string]string{
+		"networkservicemesh.io":         "kernel://ns-1/nsm-1",
+		"networkservicemesh.io/profile": "sriov",
+	}))
+	require.False(t, resp.Allowed)
+	require.EqualValues(t, http.StatusBadRequest, resp.Result.Code)
+	require.Contains(t, resp.Result.Message, "unknown injection profile: sriov")
+}
+
+func TestReview_NativeSidecars(t *testing.T) {
+	t.Setenv("NSM_INIT_CONTAINER_IMAGES", "ghcr.io/networkservicemesh/cmd-nsc-init:latest")
+	for _, tc := range []struct {
+		name                 string
+		nativeSidecars       bool
+		beforeInitContainers bool
+		initContainers       []string
+		containers           []string
+	}{
+		{
+			name:           "legacy layout",
+			initContainers: []string{"init", "cmd-nsc-init"},
+			containers:     []string{"app", "cmd-nsc"},
+		},
+		{
+			name:           "native sidecars",
+			nativeSidecars: true,
+			initContainers: []string{"init", "cmd-nsc-init", "cmd-nsc"},
+			containers:     []string{"app"},
+		},
+		{
+			name:                 "native sidecars before init containers",
+			nativeSidecars:       true,
+			beforeInitContainers: true,
+			initContainers:       []string{"cmd-nsc-init", "cmd-nsc", "init"},
+			containers:           []string{"app"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("NSM_NATIVE_SIDECARS_BEFORE_INIT_CONTAINERS", strconv.FormatBool(tc.beforeInitContainers))
+			s := newTestServer(t, nil)
+			s.nativeSidecars = tc.nativeSidecars
+			pod := newPod(map[string]string{"networkservicemesh.io": "kernel://ns-1/nsm-1"})
+			pod.Spec.InitContainers = []corev1.Container{{Name: "init", Image: "alpine"}}
+			mutated := mutatedPod(t, pod, review(t, s, pod))
+
+			require.Len(t, mutated.Spec.InitContainers, len(tc.initContainers))
+			for i, name := range tc.initContainers {
+				c := mutated.Spec.InitContainers[i]
+				require.Equal(t, name, c.Name)
+				// only the long-running NSM containers are native sidecars
+				if name == "cmd-nsc" {
+					require.Equal(t, corev1.ContainerRestartPolicyAlways, *c.RestartPolicy)
+				} else {
+					require.Nil(t, c.RestartPolicy, name)
+				}
+			}
+			require.Len(t, mutated.Spec.Containers, len(tc.containers))
+			for i, name := range tc.containers {
+				require.Equal(t, name, mutated.Spec.Containers[i].Name)
+				require.Nil(t, mutated.Spec.Containers[i].RestartPolicy, name)
+			}
+		})
+	}
+}
+
+func TestIsNativeSidecarsEnabled(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		nativeSidecars bool
+		gitVersion     string
+		enabled        bool
+	}{
+		{name: "disabled", gitVersion: "v1.30.0"},
+		{name: "supported", nativeSidecars: true, gitVersion: "v1.30.0", enabled: true},
+		{name: "not supported", nativeSidecars: true, gitVersion: "v1.28.0"},
+		{name: "unknown version", nativeSidecars: true, gitVersion: "unknown"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			conf := configtest.New(t)
+			conf.NativeSidecars = tc.nativeSidecars
+			clientset := fake.NewSimpleClientset()
+			clientset.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: tc.gitVersion}
+			require.Equal(t, tc.enabled, isNativeSidecarsEnabled(conf, clientset, zap.NewNop().Sugar()))
+		})
+	}
+}
+
+func TestReview_Sockets(t *testing.T) {
+	t.Setenv("NSM_SPIRE_SOCKET_MOUNT_PATH", "/run/spiffe")
+	t.Setenv("NSM_SPIRE_SOCKET_HOST_PATH", "/var/run/spire-agent")
+	t.Setenv("NSM_SPIRE_SOCKET_FILE_NAME", "api.sock")
+	t.Setenv("NSM_SPIRE_SOCKET_CSI_DRIVER", "csi.spire.example.com")
+	t.Setenv("NSM_NSM_SOCKET_MOUNT_PATH", "/run/nsm")
+	t.Setenv("NSM_NSM_SOCKET_HOST_PATH", "/var/run/nsm")
+	t.Setenv("NSM_NSM_SOCKET_CSI_DRIVER", "csi.nsm.example.com")
+	for _, tc := range []struct {
+		name       string
+		volumeType string
+		level      string
+		csi        bool
+	}{
+		{name: "auto in privileged namespace", volumeType: "auto", level: "privileged"},
+		{name: "auto in baseline namespace", volumeType: "auto", level: "baseline", csi: true},
+		{name: "csi in privileged namespace", volumeType: "csi", level: "privileged", csi: true},
+		{name: "hostpath in baseline namespace", volumeType: "hostpath", level: "baseline"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("NSM_SOCKET_VOLUME_TYPE", tc.volumeType)
+			s := newTestServer(t, nil)
+			setNamespaceLabels(s, map[string]string{"pod-security.kubernetes.io/enforce": tc.level})
+			pod := newPod(map[string]string{"networkservicemesh.io": "kernel://ns-1/nsm-1"})
+			mutated := mutatedPod(t, pod, review(t, s, pod))
+
+			nsc := mutated.Spec.Containers[1]
+			require.Contains(t, nsc.Env, corev1.EnvVar{Name: "SPIFFE_ENDPOINT_SOCKET", Value: "unix:///run/spiffe/api.sock"})
+			require.Equal(t, []corev1.VolumeMount{
+				{Name: "spire-agent-socket", MountPath: "/run/spiffe", ReadOnly: true},
+				{Name: "nsm-socket", MountPath: "/run/nsm", ReadOnly: true},
+			}, nsc.VolumeMounts)
+
+			require.Len(t, mutated.Spec.Volumes, 2)
+			spire, nsm := mutated.Spec.Volumes[0], mutated.Spec.Volumes[1]
+			require.Equal(t, "spire-agent-socket", spire.Name)
+			require.Equal(t, "nsm-socket", nsm.Name)
+			if tc.csi {
+				require.Nil(t, spire.HostPath)
+				require.Equal(t, "csi.spire.example.com", spire.CSI.Driver)
+				require.True(t, *spire.CSI.ReadOnly)
+				require.Equal(t, "csi.nsm.example.com", nsm.CSI.Driver)
+				return
+			}
+			require.Nil(t, spire.CSI)
+			require.Equal(t, "/var/run/spire-agent", spire.HostPath.Path)
+			require.Equal(t, "/var/run/nsm", nsm.HostPath.Path)
+		})
+	}
+}
+
+// restrictedSecurityContext returns the security context of a container complying with the restricted level.
+func restrictedSecurityContext() *corev1.SecurityContext {
+	allowPrivilegeEscalation, runAsNonRoot := false, true
+	return &corev1.SecurityContext{
+		AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+		RunAsNonRoot:             &runAsNonRoot,
+		Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+		SeccompProfile:           &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+	}
+}
+
+func TestReview_RestrictedPodSecurity(t *testing.T) {
+	templatesFilePath := filepath.Join(t.TempDir(), "templates.yaml")
+	require.NoError(t, os.WriteFile(templatesFilePath, []byte(`
+- name: vpp
+  image: ghcr.io/networkservicemesh/cmd-nsc-vpp:latest
+  securityContext:
+    privileged: true
+`), 0o600))
+	for _, tc := range []struct {
+		name            string
+		envs            map[string]string
+		securityContext *corev1.SecurityContext
+		// violation is the expected part of the explanation, empty if the mutated pod complies with the level
+		violation string
+		denied    bool
+	}{
+		{
+			name:            "compliant pod",
+			securityContext: restrictedSecurityContext(),
+		},
+		{
+			name:      "violating pod",
+			violation: "regardless of injected NSM containers",
+		},
+		{
+			name:      "violating pod denied",
+			envs:      map[string]string{"NSM_POD_SECURITY_VIOLATION_POLICY": "deny"},
+			violation: "regardless of injected NSM containers",
+			denied:    true,
+		},
+		{
+			name:            "violating NSM containers",
+			envs:            map[string]string{"NSM_CONTAINER_TEMPLATES_FILE_PATH": templatesFilePath},
+			securityContext: restrictedSecurityContext(),
+			violation:       "check the admission webhook configuration",
+		},
+		{
+			name: "violating NSM containers denied",
+			envs: map[string]string{
+				"NSM_CONTAINER_TEMPLATES_FILE_PATH": templatesFilePath,
+				"NSM_POD_SECURITY_VIOLATION_POLICY": "deny",
+			},
+			securityContext: restrictedSecurityContext(),
+			violation:       "check the admission webhook configuration",
+			denied:          true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for name, value := range tc.envs {
+				t.Setenv(name, value)
+			}
+			s := newTestServer(t, nil)
+			setNamespaceLabels(s, map[string]string{"pod-security.kubernetes.io/enforce": "restricted"})
+			pod := newPod(map[string]string{"networkservicemesh.io": "kernel://ns-1/nsm-1"})
+			pod.Spec.Containers[0].SecurityContext = tc.securityContext
+			resp := review(t, s, pod)
+			if tc.denied {
+				require.False(t, resp.Allowed)
+				require.EqualValues(t, http.StatusForbidden, resp.Result.Code)
+				require.Contains(t, resp.Result.Message, tc.violation)
+				require.Nil(t, resp.Patch)
+				return
+			}
+			mutated := mutatedPod(t, pod, resp)
+			if tc.violation != "" {
+				// the warn level defaults to the enforce level, the violation of the enforce level follows its warning
+				require.Len(t, resp.Warnings, 2)
+				require.True(t, strings.HasPrefix(resp.Warnings[0], "warn: "), resp.Warnings[0])
+				for _, warning := range resp.Warnings {
+					require.Contains(t, warning, `violates PodSecurity "restricted:latest"`)
+					require.Contains(t, warning, tc.violation)
+				}
+				return
+			}
+			require.Empty(t, resp.Warnings)
+			nsc := mutated.Spec.Containers[1]
+			require.Equal(t, restrictedSecurityContext(), nsc.SecurityContext)
+			allowed, explanation := s.podSecurity.Check(psa.LevelVersion{Level: psa.LevelRestricted, Version: psa.LatestVersion()},
+				&mutated.ObjectMeta, &mutated.Spec)
+			require.True(t, allowed, explanation)
+		})
+	}
+}
+
+func TestReview_PodSecurityLabels(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		envs   map[string]string
+		labels map[string]string
+		// restricted is true if NSM containers are expected to be configured for the restricted level
+		restricted bool
+		csi        bool
+		warnings   []string
+	}{
+		{
+			name:   "privileged namespace",
+			labels: map[string]string{"pod-security.kubernetes.io/enforce": "privileged"},
+		},
+		{
+			name: "warn level stricter than enforce level",
+			labels: map[string]string{
+				"pod-security.kubernetes.io/enforce": "privileged",
+				"pod-security.kubernetes.io/warn":    "restricted",
+			},
+			restricted: true,
+			csi:        true,
+			warnings: []string{
+				`NSM containers are configured for PodSecurity level "restricted" because of the namespace policy enforce=privileged:latest, audit=privileged:latest, warn=restricted:latest`,
+				`warn: resource violates PodSecurity "restricted:latest"`,
+			},
+		},
+		{
+			name: "audit level not considered",
+			labels: map[string]string{
+				"pod-security.kubernetes.io/enforce": "privileged",
+				"pod-security.kubernetes.io/audit":   "restricted",
+			},
+			warnings: []string{`audit: resource violates PodSecurity "restricted:latest"`},
+		},
+		{
+			name: "only enforce level considered",
+			envs: map[string]string{"NSM_POD_SECURITY_MODES": "enforce"},
+			labels: map[string]string{
+				"pod-security.kubernetes.io/enforce": "privileged",
+				"pod-security.kubernetes.io/warn":    "restricted",
+			},
+			warnings: []string{`warn: resource violates PodSecurity "restricted:latest"`},
+		},
+		{
+			name:       "cluster defaults",
+			envs:       map[string]string{"NSM_POD_SECURITY_DEFAULTS": "enforce:baseline,warn:restricted"},
+			restricted: true,
+			csi:        true,
+			warnings: []string{
+				`NSM containers are configured for PodSecurity level "restricted" because of the namespace policy enforce=baseline:latest, audit=privileged:latest, warn=restricted:latest`,
+				`warn: resource violates PodSecurity "restricted:latest"`,
+			},
+		},
+		{
+			name:   "invalid labels",
+			labels: map[string]string{"pod-security.kubernetes.io/enforce": "strict"},
+			// pod security admission evaluates invalid levels as restricted
+			restricted: true,
+			csi:        true,
+			warnings: []string{
+				"invalid PodSecurity labels of namespace ns",
+				`resource violates PodSecurity "restricted:latest"`,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for name, value := range tc.envs {
+				t.Setenv(name, value)
+			}
+			s := newTestServer(t, nil)
+			setNamespaceLabels(s, tc.labels)
+			pod := newPod(map[string]string{"networkservicemesh.io": "kernel://ns-1/nsm-1"})
+			resp := review(t, s, pod)
+			mutated := mutatedPod(t, pod, resp)
+
+			require.Len(t, resp.Warnings, len(tc.warnings), resp.Warnings)
+			for i, warning := range tc.warnings {
+				require.Contains(t, resp.Warnings[i], warning)
+			}
+			nsc := mutated.Spec.Containers[1]
+			if tc.restricted {
+				require.Equal(t, restrictedSecurityContext(), nsc.SecurityContext)
+			} else {
+				require.Nil(t, nsc.SecurityContext)
+			}
+			require.Equal(t, tc.csi, mutated.Spec.Volumes[0].CSI != nil)
+		})
+	}
+}
+
+func TestReview_MinimalPatch(t *testing.T) {
+	t.Setenv("NSM_LABELS", "nsm-client:true")
+	t.Setenv("NSM_INIT_CONTAINER_IMAGES", "ghcr.io/networkservicemesh/cmd-nsc-init:latest")
+	pod := newPod(map[string]string{"networkservicemesh.io": "kernel://ns-1/nsm-1"})
+	// the pod is already mutated by other webhooks
+	pod.Labels = map[string]string{"app": "web", "security.istio.io/tlsMode": "istio"}
+	pod.Spec.InitContainers = []corev1.Container{{Name: "istio-init", Image: "istio/proxyv2"}}
+	pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: "istio-proxy", Image: "istio/proxyv2"})
+	pod.Spec.Volumes = []corev1.Volume{{Name: "istio-envoy", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}}
+	resp := review(t, newTestServer(t, nil), pod)
+	require.True(t, resp.Allowed, resp.Result)
+
+	var patch []map[string]interface{}
+	require.NoError(t, json.Unmarshal(resp.Patch, &patch))
+	var paths []string
+	for _, op := range patch {
+		require.Equal(t, "add", op["op"], op)
+		paths = append(paths, op["path"].(string))
+	}
+	require.ElementsMatch(t, []string{
+		"/metadata/annotations/networkservicemesh.io~1injection-status",
+		"/metadata/labels/nsm-client",
+		"/spec/initContainers/1",
+		"/spec/containers/2",
+		"/spec/volumes/1",
+		"/spec/volumes/2",
+	}, paths)
+
+	mutated := mutatedPod(t, pod, resp)
+	require.Equal(t, pod.Spec.InitContainers[0], mutated.Spec.InitContainers[0])
+	require.Equal(t, pod.Spec.Containers, mutated.Spec.Containers[:2])
+	require.Equal(t, pod.Spec.Volumes[0], mutated.Spec.Volumes[0])
+	require.Equal(t, "istio", mutated.Labels["security.istio.io/tlsMode"])
+}
+
+func TestReview_MinimalPatch_UnknownFields(t *testing.T) {
+	raw := `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"p","namespace":"ns","annotations":{"networkservicemesh.io":"kernel://ns-1/nsm-1"}},` +
+		`"spec":{"containers":[{"name":"app","image":"alpine","futureField":"value"}],"futureField":{"enabled":true}}}`
+	resp := newTestServer(t, nil).Review(context.Background(), &admissionv1.AdmissionRequest{
+		UID:       "uid",
+		Operation: admissionv1.Create,
+		Namespace: testNamespace,
+		Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+		Object:    runtime.RawExtension{Raw: []byte(raw)},
+	})
+	require.True(t, resp.Allowed, resp.Result)
+	patch, err := jsonpatch.DecodePatch(resp.Patch)
+	require.NoError(t, err)
+	mutated, err := patch.Apply([]byte(raw))
+	require.NoError(t, err)
+	// the fields unknown to the webhook are not touched by the patch
+	require.Contains(t, string(mutated), `"futureField":{"enabled":true}`)
+	require.Contains(t, string(mutated), `"futureField":"value"`)
+	require.Contains(t, string(mutated), `"image":"ghcr.io/networkservicemesh/cmd-nsc:latest"`)
+}
+
+func TestReview_ResourceRuleTargets(t *testing.T) {
+	t.Setenv("NSM_INIT_CONTAINER_IMAGES", "ghcr.io/networkservicemesh/cmd-nsc-init:latest")
+	for target, containers := range map[string][]string{
+		"":               {"cmd-nsc-init"},
+		"initContainers": {"cmd-nsc-init"},
+		"containers":     {"cmd-nsc"},
+		"all":            {"cmd-nsc-init", "cmd-nsc"},
+	} {
+		t.Run(target, func(t *testing.T) {
+			rulesFilePath := filepath.Join(t.TempDir(), "rules.yaml")
+			require.NoError(t, os.WriteFile(rulesFilePath, []byte(`
+- networkService: vpp-.*
+  resourceName: hugepages-2Mi
+  quantity: 512Mi
+  target: "`+target+`"
+`), 0o600))
+			t.Setenv("NSM_RESOURCE_RULES_FILE_PATH", rulesFilePath)
+			pod := newPod(map[string]string{"networkservicemesh.io": "memif://vpp-ns/nsm-1,memif://vpp-ns2/nsm-2,kernel://ns/nsm-3"})
+			mutated := mutatedPod(t, pod, review(t, newTestServer(t, nil), pod))
+
+			withResources := map[string]bool{}
+			for _, c := range append(mutated.Spec.InitContainers, mutated.Spec.Containers[1:]...) {
+				if quantity, ok := c.Resources.Limits["hugepages-2Mi"]; ok {
+					withResources[c.Name] = true
+					// the quantity is requested for each matched NS URL
+					require.Equal(t, "1Gi", quantity.String(), c.Name)
+				}
+			}
+			require.Len(t, withResources, len(containers))
+			for _, name := range containers {
+				require.True(t, withResources[name], name)
+			}
+		})
+	}
+}
+
+func TestReview_MergeStrategies(t *testing.T) {
+	namespaceAnnotations := map[string]string{"networkservicemesh.io": "kernel://mgmt/mgmt0"}
+	for _, tc := range []struct {
+		name       string
+		strategy   string
+		annotation string
+		nsURLs     string
+		warnings   int
+	}{
+		{name: "override without resource annotation", strategy: "override", nsURLs: "kernel://mgmt/mgmt0"},
+		{name: "override", strategy: "override", annotation: "kernel://app/nsm-1", nsURLs: "kernel://app/nsm-1"},
+		{name: "union without resource annotation", strategy: "union", nsURLs: "kernel://mgmt/mgmt0"},
+		{name: "union", strategy: "union", annotation: "kernel://app/nsm-1", nsURLs: "kernel://mgmt/mgmt0,kernel://app/nsm-1"},
+		{name: "union of same interface", strategy: "union", annotation: "kernel://app/nsm-1,kernel://mgmt-v2/mgmt0", nsURLs: "kernel://mgmt-v2/mgmt0,kernel://app/nsm-1"},
+		{name: "union of same URL", strategy: "union", annotation: "kernel://mgmt/mgmt0", nsURLs: "kernel://mgmt/mgmt0"},
+		{name: "namespace only", strategy: "namespace-only", annotation: "kernel://app/nsm-1", nsURLs: "kernel://mgmt/mgmt0", warnings: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("NSM_ANNOTATION_MERGE_STRATEGY", tc.strategy)
+			s := newTestServer(t, namespaceAnnotations)
+			var annotations map[string]string
+			if tc.annotation != "" {
+				annotations = map[string]string{"networkservicemesh.io": tc.annotation}
+			}
+			for _, object := range []runtime.Object{newPod(annotations), newDeployment(annotations, nil)} {
+				resp := review(t, s, object)
+				require.True(t, resp.Allowed, resp.Result)
+				require.Len(t, resp.Warnings, tc.warnings)
+				require.Contains(t, string(resp.Patch), `{"name":"NSM_NETWORK_SERVICES","value":"`+tc.nsURLs+`"}`)
+			}
+		})
+	}
+}
+
+func TestServeHealthChecks(t *testing.T) {
+	for name, operationalListenOn := range map[string]string{
+		"admission listener":   "",
+		"operational listener": ":8080",
+	} {
+		t.Run(name, func(t *testing.T) {
+			conf := configtest.New(t)
+			conf.ListenOn, conf.OperationalListenOn = ":8443", operationalListenOn
+			s := echo.New()
+			liveness, readiness := health.NewChecker(time.Second), health.NewChecker(time.Second)
+			servers := serveHealthChecks(conf, s, liveness, readiness)
+			require.Equal(t, s, servers[0])
+			ops := s
+			if operationalListenOn != "" {
+				require.Len(t, servers, 2)
+				ops = servers[1]
+				require.NotEqual(t, s, ops)
+			} else {
+				require.Len(t, servers, 1)
+			}
+			for _, path := range []string{"/healthz", "/readyz", "/ready"} {
+				rec := httptest.NewRecorder()
+				ops.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, http.NoBody))
+				require.Equal(t, http.StatusOK, rec.Code, path)
+				if ops != s {
+					// the operational endpoints aren't exposed by the admission listener
+					rec = httptest.NewRecorder()
+					s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, http.NoBody))
+					require.Equal(t, http.StatusNotFound, rec.Code, path)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	for name, sample := range map[string]struct {
+		args                []string
+		expectedKubeconfig  string
+		expectedKubeContext string
+	}{
+		"no flags": {
+			expectedKubeconfig:  "/env/kubeconfig",
+			expectedKubeContext: "env",
+		},
+		"kubeconfig flag": {
+			args:                []string{"--kubeconfig", "/flag/kubeconfig"},
+			expectedKubeconfig:  "/flag/kubeconfig",
+			expectedKubeContext: "env",
+		},
+		"context flag": {
+			args:                []string{"--context=flag"},
+			expectedKubeconfig:  "/env/kubeconfig",
+			expectedKubeContext: "flag",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("NSM_KUBECONFIG", "/env/kubeconfig")
+			t.Setenv("NSM_KUBE_CONTEXT", "env")
+			conf := configtest.New(t)
+			require.NoError(t, parseFlags(conf, sample.args))
+			require.Equal(t, sample.expectedKubeconfig, conf.Kubeconfig)
+			require.Equal(t, sample.expectedKubeContext, conf.KubeContext)
+		})
+	}
+	require.Error(t, parseFlags(configtest.New(t), []string{"--unknown"}))
+}